@@ -0,0 +1,123 @@
+package blob
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// AnchorKind - Point of bounding box which is used as blob's position in geometric tests (line crossing, zones and etc.)
+type AnchorKind int
+
+const (
+	// AnchorCenter - Center of bounding box (blob's track is used as is)
+	AnchorCenter = AnchorKind(iota)
+	// AnchorBottomCenter - Middle of the bottom edge. Good choice for vehicles seen from a pole camera: that is the point touching the road
+	AnchorBottomCenter
+	// AnchorTopCenter - Middle of the top edge
+	AnchorTopCenter
+	// AnchorTopLeft - Top-left corner
+	AnchorTopLeft
+	// AnchorTopRight - Top-right corner
+	AnchorTopRight
+	// AnchorBottomLeft - Bottom-left corner
+	AnchorBottomLeft
+	// AnchorBottomRight - Bottom-right corner
+	AnchorBottomRight
+	// AnchorCustom - Point defined by fractional offsets of bounding box (see NewCustomAnchor)
+	AnchorCustom
+)
+
+// String - Returns text representation of AnchorKind
+func (kind AnchorKind) String() string {
+	switch kind {
+	case AnchorCenter:
+		return "center"
+	case AnchorBottomCenter:
+		return "bottom-center"
+	case AnchorTopCenter:
+		return "top-center"
+	case AnchorTopLeft:
+		return "top-left"
+	case AnchorTopRight:
+		return "top-right"
+	case AnchorBottomLeft:
+		return "bottom-left"
+	case AnchorBottomRight:
+		return "bottom-right"
+	case AnchorCustom:
+		return "custom"
+	default:
+		return "unknown"
+	}
+}
+
+// Anchor - Point of blob's bounding box which is used in geometric tests
+//
+// Zero value is AnchorCenter, so the blob's track (centroids) is used
+type Anchor struct {
+	Kind AnchorKind
+	// OffsetX - Fraction of bounding box width from its left edge (0 - left edge, 1 - right edge). Used by AnchorCustom only
+	OffsetX float64
+	// OffsetY - Fraction of bounding box height from its top edge (0 - top edge, 1 - bottom edge). Used by AnchorCustom only
+	OffsetY float64
+}
+
+// NewAnchor - Constructor for Anchor of predefined kind
+func NewAnchor(kind AnchorKind) Anchor {
+	return Anchor{Kind: kind}
+}
+
+// NewCustomAnchor - Constructor for Anchor defined by fractional offsets of bounding box. Both offsets must be in [0; 1]
+func NewCustomAnchor(offsetX, offsetY float64) (Anchor, error) {
+	if offsetX < 0 || offsetX > 1 || offsetY < 0 || offsetY > 1 {
+		return Anchor{}, fmt.Errorf("anchor offsets must be in [0; 1], but got (%f, %f)", offsetX, offsetY)
+	}
+	return Anchor{Kind: AnchorCustom, OffsetX: offsetX, OffsetY: offsetY}, nil
+}
+
+// offsets - Returns fractional offsets of bounding box for the anchor
+func (anchor Anchor) offsets() (float64, float64) {
+	switch anchor.Kind {
+	case AnchorBottomCenter:
+		return 0.5, 1
+	case AnchorTopCenter:
+		return 0.5, 0
+	case AnchorTopLeft:
+		return 0, 0
+	case AnchorTopRight:
+		return 1, 0
+	case AnchorBottomLeft:
+		return 0, 1
+	case AnchorBottomRight:
+		return 1, 1
+	case AnchorCustom:
+		return anchor.OffsetX, anchor.OffsetY
+	default:
+		return 0.5, 0.5
+	}
+}
+
+// Point - Returns anchor point of given bounding box
+func (anchor Anchor) Point(rect image.Rectangle) image.Point {
+	offsetX, offsetY := anchor.offsets()
+	return image.Pt(
+		rect.Min.X+int(math.Round(offsetX*float64(rect.Dx()))),
+		rect.Min.Y+int(math.Round(offsetY*float64(rect.Dy()))),
+	)
+}
+
+// Track - Returns track of blob in terms of anchor points
+//
+// For AnchorCenter blob's track is returned as is (so Kalman-filtered centroids are kept). For other kinds points are evaluated from history of bounding boxes
+func (anchor Anchor) Track(b Blobie) []image.Point {
+	if anchor.Kind == AnchorCenter {
+		return b.GetTrack()
+	}
+	rects := b.GetRectTrack()
+	track := make([]image.Point, len(rects))
+	for i := range rects {
+		track[i] = anchor.Point(rects[i])
+	}
+	return track
+}
@@ -0,0 +1,104 @@
+package blob
+
+import (
+	"image"
+	"testing"
+)
+
+func TestAnchorPoint(t *testing.T) {
+	rect := image.Rect(10, 20, 50, 100)
+	custom, err := NewCustomAnchor(0.25, 0.75)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	cases := []struct {
+		anchor  Anchor
+		correct image.Point
+	}{
+		{Anchor{}, image.Pt(30, 60)},
+		{NewAnchor(AnchorBottomCenter), image.Pt(30, 100)},
+		{NewAnchor(AnchorTopCenter), image.Pt(30, 20)},
+		{NewAnchor(AnchorTopLeft), image.Pt(10, 20)},
+		{NewAnchor(AnchorTopRight), image.Pt(50, 20)},
+		{NewAnchor(AnchorBottomLeft), image.Pt(10, 100)},
+		{NewAnchor(AnchorBottomRight), image.Pt(50, 100)},
+		{custom, image.Pt(20, 80)},
+	}
+	for _, c := range cases {
+		if pt := c.anchor.Point(rect); pt != c.correct {
+			t.Errorf("Anchor '%s' should be %v, but got %v", c.anchor.Kind, c.correct, pt)
+		}
+	}
+	if _, err := NewCustomAnchor(1.5, 0); err == nil {
+		t.Error("Custom anchor with offset out of [0; 1] should produce an error")
+	}
+}
+
+func TestAnchorTrack(t *testing.T) {
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 3}
+	b := NewSimpleBlobie(image.Rect(0, 0, 20, 40), &options)
+	for i := 1; i < 5; i++ {
+		b.Update(NewSimpleBlobie(image.Rect(i*10, 0, i*10+20, 40), &options))
+	}
+	track := NewAnchor(AnchorBottomCenter).Track(b)
+	correct := []image.Point{image.Pt(30, 40), image.Pt(40, 40), image.Pt(50, 40)}
+	if len(track) != len(correct) {
+		t.Errorf("Length of anchor track should be %d, but got %d", len(correct), len(track))
+		return
+	}
+	for i := range correct {
+		if track[i] != correct[i] {
+			t.Errorf("Anchor point #%d should be %v, but got %v", i, correct[i], track[i])
+		}
+	}
+}
+
+func TestCountingLineAnchor(t *testing.T) {
+	// Tall blob moves down: its bottom edge reaches the line much earlier than its center
+	center, err := NewCountingLine("center", image.Pt(0, 100), image.Pt(200, 100))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	bottom, err := NewCountingLine("bottom", image.Pt(0, 100), image.Pt(200, 100))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	bottom.Anchor = NewAnchor(AnchorBottomCenter)
+	options := BlobOptions{ClassID: 1, ClassName: "truck", MaxPointsInTrack: 10}
+	b := NewSimpleBlobie(image.Rect(80, 0, 120, 80), &options)
+	centerFrame, bottomFrame := -1, -1
+	for i := 1; i < 10; i++ {
+		b.Update(NewSimpleBlobie(image.Rect(80, i*10, 120, 80+i*10), &options))
+		if _, ok := center.Check(b); ok {
+			centerFrame = i
+		}
+		if _, ok := bottom.Check(b); ok {
+			bottomFrame = i
+		}
+	}
+	// Anchors touch the line on frames 2 and 6, but crossing is counted when they leave it on the other side
+	if bottomFrame != 3 || centerFrame != 7 {
+		t.Errorf("Line should be crossed by bottom-center on frame %d and by center on frame %d, but got %d and %d", 3, 7, bottomFrame, centerFrame)
+	}
+}
+
+func TestCheckObliqueLineCrossingWithAnchor(t *testing.T) {
+	options := BlobOptions{ClassID: 1, ClassName: "truck", MaxPointsInTrack: 10}
+	crossingFrame := func(anchor Anchor) int {
+		b := NewSimpleBlobie(image.Rect(80, 0, 120, 80), &options)
+		for i := 1; i < 10; i++ {
+			b.Update(NewSimpleBlobie(image.Rect(80, i*10, 120, 80+i*10), &options))
+			if direction := b.CheckObliqueLineCrossingWithAnchor(anchor, 0, 100, 200, 100); direction != CrossingNone {
+				return i
+			}
+		}
+		return -1
+	}
+	bottomFrame, centerFrame := crossingFrame(NewAnchor(AnchorBottomCenter)), crossingFrame(NewAnchor(AnchorCenter))
+	if bottomFrame != 3 || centerFrame != 7 {
+		t.Errorf("Line should be crossed by bottom-center on frame %d and by center on frame %d, but got %d and %d", 3, 7, bottomFrame, centerFrame)
+	}
+}
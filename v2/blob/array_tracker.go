@@ -2,16 +2,39 @@ package blob
 
 import (
 	"math"
+	"time"
 
+	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 )
 
+// MatchingStrategy - Strategy of associating new detections with existing blobs
+type MatchingStrategy int
+
+const (
+	// MatchingGreedy - Each detection is matched to the nearest existing blob independently of other detections (default)
+	MatchingGreedy = MatchingStrategy(iota)
+	// MatchingHungarian - Globally optimal one-to-one assignment over detection x blob cost matrix (Hungarian algorithm)
+	MatchingHungarian
+)
+
 // Blobies - Array of blobs
 type Blobies struct {
 	Objects              map[uuid.UUID]Blobie
 	maxNoMatch           int
 	minThresholdDistance float64
+	diagonalGateFactor   float64
+	predictionWindow     int
 	maxPointsInTrack     int
+	minHits              int
+	confirmationWindow   int
+	matchingStrategy     MatchingStrategy
+	costMetric           CostMetric
+	minOverlap           float64
+	classGating          ClassGating
+	classCompatibility   *ClassCompatibility
+	handlers             trackEventHandlers
+	frameTime            time.Time
 
 	DrawingOptions *DrawOptions
 }
@@ -21,59 +44,266 @@ type Blobies struct {
 // Default values are:
 // maxNoMatch = 5
 // minThresholdDistance = 15
+// diagonalGateFactor = 0.5
+// predictionWindow = 5
 // maxPointsInTrack = 10
+// minHits = 1
+// confirmationWindow = 1
+// matchingStrategy = MatchingGreedy
+// costMetric = CostDistance
+// minOverlap = 0.3
+// classGating = ClassGatingNone
 //
 func NewBlobiesDefaults() *Blobies {
 	return &Blobies{
 		Objects:              make(map[uuid.UUID]Blobie),
 		maxNoMatch:           5,
 		minThresholdDistance: 15,
+		diagonalGateFactor:   0.5,
+		predictionWindow:     5,
 		maxPointsInTrack:     10,
+		minHits:              1,
+		confirmationWindow:   1,
+		matchingStrategy:     MatchingGreedy,
+		costMetric:           CostDistance,
+		minOverlap:           0.3,
+		classGating:          ClassGatingNone,
 		DrawingOptions:       NewDrawOptionsDefault(),
 	}
 }
 
+// SetMatchingStrategy - Sets strategy of associating new detections with existing blobs
+func (bt *Blobies) SetMatchingStrategy(strategy MatchingStrategy) {
+	bt.matchingStrategy = strategy
+}
+
+// SetCostMetric - Sets metric of associating new detections with existing blobs
+// Returns error for unknown metric (see WithCostMetric)
+func (bt *Blobies) SetCostMetric(metric CostMetric) error {
+	return WithCostMetric(metric, bt.minOverlap)(bt)
+}
+
+// SetMinOverlap - Sets gating threshold for IoU-family metrics: detection can be matched to existing blob only if overlap value is not less than this threshold
+// Returns error when threshold is out of [-1; 1] (see WithCostMetric)
+//
+// Note: this threshold is not used when metric is CostDistance (minThresholdDistance and blob's diagonal are used instead)
+func (bt *Blobies) SetMinOverlap(minOverlap float64) error {
+	return WithCostMetric(bt.costMetric, minOverlap)(bt)
+}
+
+// SetClassGating - Sets mode of class-aware association
+//
+// compatibility - set of compatible class pairs. It is used only when mode is ClassGatingMatrix and could be nil otherwise
+// Returns error for unknown mode or for missing compatibility in ClassGatingMatrix mode (see WithClassGating)
+func (bt *Blobies) SetClassGating(mode ClassGating, compatibility *ClassCompatibility) error {
+	return WithClassGating(mode, compatibility)(bt)
+}
+
 // MatchToExisting Check if some of blobs already exists
-func (bt *Blobies) MatchToExisting(blobies []Blobie) {
+//
+// Timestamp of the frame (which is passed to track events) is the latest timestamp of provided blobs.
+// If there are no blobs then timestamp of the previous frame is used, so events keep video time. Use MatchToExistingWithTime to provide timestamp explicitly
+//
+// Error is returned when some of existing blobs can't be updated by matched detection (e.g. detection is of another Blobie implementation).
+// Such blobs are considered not matched on the frame, while the rest of detections are processed as usual
+func (bt *Blobies) MatchToExisting(blobies []Blobie) error {
+	frameTime := time.Time{}
+	for i := range blobies {
+		timestamps := blobies[i].GetTimestamps()
+		if len(timestamps) == 0 {
+			continue
+		}
+		if last := timestamps[len(timestamps)-1]; last.After(frameTime) {
+			frameTime = last
+		}
+	}
+	if frameTime.IsZero() {
+		frameTime = bt.frameTime
+	}
+	return bt.MatchToExistingWithTime(blobies, frameTime)
+}
+
+// MatchToExistingWithTime Check if some of blobs already exists. frameTime is timestamp of the frame which blobs have been detected on
+// Errors are handled the same way as in MatchToExisting
+func (bt *Blobies) MatchToExistingWithTime(blobies []Blobie, frameTime time.Time) error {
+	bt.frameTime = frameTime
 	bt.prepare()
+	var err error
+	switch bt.matchingStrategy {
+	case MatchingHungarian:
+		err = bt.matchHungarian(blobies)
+	default:
+		err = bt.matchGreedy(blobies)
+	}
+	bt.RefreshNoMatch()
+	return err
+}
+
+// matchGreedy - Matches each detection to the nearest existing blob
+// Returns the first error of updating existing blobs
+func (bt *Blobies) matchGreedy(blobies []Blobie) error {
+	var firstErr error
 	for i := range blobies {
 		minUUID := uuid.UUID{}
 		minDistance := math.MaxFloat64
 		for j := range (*bt).Objects {
-			dist := distanceBetweenPoints(blobies[i].GetCenter(), (*bt).Objects[j].GetCenter())
-			distPredicted := distanceBetweenPoints(blobies[i].GetCenter(), (*bt).Objects[j].GetPredictedNextPosition())
-			dist = minf64(dist, distPredicted)
+			dist := bt.matchingCost(blobies[i], (*bt).Objects[j])
 			if dist < minDistance {
 				minDistance = dist
 				minUUID = j
 			}
 		}
-		if minDistance < blobies[i].GetDiagonal()*0.5 || minDistance < bt.minThresholdDistance {
-			bt.Objects[minUUID].Update(blobies[i])
+		if bt.isGated(blobies[i], minDistance) {
+			if err := bt.update(minUUID, blobies[i]); err != nil && firstErr == nil {
+				firstErr = err
+			}
 		} else {
 			bt.Register(blobies[i])
 		}
 	}
-	bt.RefreshNoMatch()
+	return firstErr
+}
+
+// matchHungarian - Matches detections to existing blobs via globally optimal assignment
+//
+// Each existing blob gets at most one detection per call. Detections which have not been assigned (or which assignment did not pass gating) are registered as new blobs
+// Returns the first error of updating existing blobs
+func (bt *Blobies) matchHungarian(blobies []Blobie) error {
+	ids := make([]uuid.UUID, 0, len(bt.Objects))
+	for id := range bt.Objects {
+		ids = append(ids, id)
+	}
+	cost := make([][]float64, len(blobies))
+	for i := range blobies {
+		cost[i] = make([]float64, len(ids))
+		for j, id := range ids {
+			dist := bt.matchingCost(blobies[i], bt.Objects[id])
+			if !bt.isGated(blobies[i], dist) {
+				dist = math.Inf(1)
+			}
+			cost[i][j] = dist
+		}
+	}
+	assignment := SolveAssignment(cost)
+	var firstErr error
+	for _, match := range assignment.Matches {
+		if err := bt.update(ids[match[1]], blobies[match[0]]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, row := range assignment.UnmatchedRows {
+		bt.Register(blobies[row])
+	}
+	return firstErr
+}
+
+// matchingCost - Returns cost of associating detection with existing blob
+//
+// For CostDistance it is distance between detection center and either current or predicted center of existing blob (the smallest one).
+// For IoU-family metrics it is (1 - overlap) between detection rectangle and either current or predicted rectangle of existing blob (the smallest one).
+// Penalty for class mismatch is added on top of it. If classes are not compatible then cost is +Inf
+func (bt *Blobies) matchingCost(detection, existing Blobie) float64 {
+	penalty, ok := bt.classPenalty(detection, existing)
+	if !ok {
+		return math.Inf(1)
+	}
+	return bt.geometryCost(detection, existing) + penalty
+}
+
+// classPenalty - Returns penalty for associating classes of detection and existing blob and whether such association is allowed
+func (bt *Blobies) classPenalty(detection, existing Blobie) (float64, bool) {
+	switch bt.classGating {
+	case ClassGatingStrict:
+		return 0, detection.GetClassID() == existing.GetClassID()
+	case ClassGatingMatrix:
+		return bt.classCompatibility.Penalty(detection.GetClassID(), existing.GetClassID())
+	default:
+		return 0, true
+	}
+}
+
+// geometryCost - Returns cost of associating detection with existing blob based on cost metric only
+func (bt *Blobies) geometryCost(detection, existing Blobie) float64 {
+	switch bt.costMetric {
+	case CostIoU, CostGIoU, CostDIoU, CostCIoU:
+		overlap := overlapSimilarity(bt.costMetric, detection.GetCurrentRect(), existing.GetCurrentRect())
+		overlapPredicted := overlapSimilarity(bt.costMetric, detection.GetCurrentRect(), predictedRect(existing))
+		return 1.0 - maxf64(overlap, overlapPredicted)
+	default:
+		dist := distanceBetweenPoints(detection.GetCenter(), existing.GetCenter())
+		distPredicted := distanceBetweenPoints(detection.GetCenter(), existing.GetPredictedNextPosition())
+		return minf64(dist, distPredicted)
+	}
+}
+
+// isGated - Checks if association cost is small enough for detection to be matched
+func (bt *Blobies) isGated(detection Blobie, cost float64) bool {
+	switch bt.costMetric {
+	case CostIoU, CostGIoU, CostDIoU, CostCIoU:
+		return cost <= 1.0-bt.minOverlap
+	default:
+		return cost < detection.GetDiagonal()*bt.diagonalGateFactor || cost < bt.minThresholdDistance
+	}
 }
 
 // RefreshNoMatch - Refresh state of each blob
+//
+// Tentative blob becomes confirmed when it gets minHits detections within confirmationWindow frames, otherwise it is deleted.
+// Confirmed blob becomes lost when it has not been matched on current frame and becomes confirmed again when it is matched.
+// Any blob is deleted when it has not been matched for maxNoMatch frames in a row.
 func (bt *Blobies) RefreshNoMatch() {
 	for i, b := range (*bt).Objects {
 		if b.Exists() == false {
 			b.IncrementNoMatchTimes()
 		}
-		if b.NoMatchTimes() >= 5 {
+		switch b.GetState() {
+		case TrackTentative:
+			if b.Hits() >= bt.minHits {
+				b.SetState(TrackConfirmed)
+				bt.emit(bt.handlers.confirmed, b, ReasonNone)
+			} else if b.Age()+1 >= bt.confirmationWindow {
+				b.SetTracking(false)
+				bt.deregister(i, ReasonNotConfirmed)
+				continue
+			}
+		case TrackConfirmed:
+			if b.Exists() == false {
+				b.SetState(TrackLost)
+				bt.emit(bt.handlers.lost, b, ReasonNone)
+			}
+		case TrackLost:
+			if b.Exists() == true {
+				b.SetState(TrackConfirmed)
+			}
+		}
+		if b.NoMatchTimes() >= bt.maxNoMatch {
 			b.SetTracking(false)
-			bt.deregister(i)
+			bt.deregister(i, ReasonMaxNoMatch)
 		}
 	}
 }
 
+// ObjectsInState - Returns blobs which are in one of provided states
+//
+// Example: bt.ObjectsInState(TrackConfirmed, TrackLost) returns blobs which are not tentative anymore
+func (bt *Blobies) ObjectsInState(states ...TrackState) map[uuid.UUID]Blobie {
+	filtered := make(map[uuid.UUID]Blobie)
+	for id, b := range bt.Objects {
+		for _, state := range states {
+			if b.GetState() == state {
+				filtered[id] = b
+				break
+			}
+		}
+	}
+	return filtered
+}
+
 func (bt *Blobies) prepare() {
 	for i := range bt.Objects {
 		bt.Objects[i].SetExists(false)
-		bt.Objects[i].PredictNextPosition(bt.maxNoMatch)
+		bt.Objects[i].IncrementAge()
+		bt.Objects[i].PredictNextPosition(bt.predictionWindow)
 	}
 }
 
@@ -81,11 +311,35 @@ func (bt *Blobies) prepare() {
 func (bt *Blobies) Register(b Blobie) error {
 	newUUID := uuid.NewV4()
 	b.SetID(newUUID)
+	b.SetState(TrackTentative)
 	bt.Objects[newUUID] = b
+	bt.emit(bt.handlers.registered, b, ReasonNone)
+	if b.Hits() >= bt.minHits {
+		b.SetState(TrackConfirmed)
+		bt.emit(bt.handlers.confirmed, b, ReasonNone)
+	}
+	return nil
+}
+
+// update - Updates existing blob with provided uuid by matched detection
+// If blob can't be updated, then it is considered not matched on the frame
+func (bt *Blobies) update(guid uuid.UUID, detection Blobie) error {
+	b := bt.Objects[guid]
+	if err := b.Update(detection); err != nil {
+		b.SetExists(false)
+		return errors.Wrapf(err, "can't update blob %s", guid)
+	}
+	bt.emit(bt.handlers.updated, b, ReasonNone)
 	return nil
 }
 
 // deregister - deregister blob with provided uuid
-func (bt *Blobies) deregister(guid uuid.UUID) {
+func (bt *Blobies) deregister(guid uuid.UUID, reason TerminationReason) {
+	b, ok := bt.Objects[guid]
+	if !ok {
+		return
+	}
+	b.SetState(TrackDeleted)
 	delete(bt.Objects, guid)
+	bt.emit(bt.handlers.deregistered, b, reason)
 }
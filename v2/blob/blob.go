@@ -14,7 +14,10 @@ type Blobie interface {
 	GetCurrentRect() image.Rectangle
 	GetPredictedNextPosition() image.Point
 	GetTrack() []image.Point
+	GetRectTrack() []image.Rectangle
 	GetTimestamps() []time.Time
+	GetSpeed() (Speed, bool)
+	SetSpeedOptions(options *SpeedOptions)
 	GetDiagonal() float64
 	GetClassID() int
 	GetClassName() string
@@ -24,6 +27,11 @@ type Blobie interface {
 	NoMatchTimes() int
 	IncrementNoMatchTimes()
 	SetExists(isExists bool)
+	GetState() TrackState
+	SetState(state TrackState)
+	Hits() int
+	Age() int
+	IncrementAge()
 	SetTracking(isStillBeingTracked bool)
 	SetID(id uuid.UUID)
 	PredictNextPosition(n int)
@@ -34,4 +42,7 @@ type Blobie interface {
 	IsCrossedTheLineWithShift(vertical, leftX, rightX int, direction bool, shift int) bool
 	IsCrossedTheObliqueLine(leftX, leftY, rightX, rightY int, direction bool) bool
 	IsCrossedTheObliqueLineWithShift(leftX, leftY, rightX, rightY int, direction bool, shift int) bool
+	CheckObliqueLineCrossing(startX, startY, endX, endY int) CrossingDirection
+	CheckObliqueLineCrossingWithShift(startX, startY, endX, endY int, shift int) CrossingDirection
+	CheckObliqueLineCrossingWithAnchor(anchor Anchor, startX, startY, endX, endY int) CrossingDirection
 }
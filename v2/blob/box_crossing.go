@@ -0,0 +1,202 @@
+package blob
+
+import (
+	"image"
+	"math"
+)
+
+// CrossingMode - Defines what part of blob is tested against the counting line
+type CrossingMode int
+
+const (
+	// CrossingModePoint - Movement of anchor point is tested (see Anchor)
+	CrossingModePoint = CrossingMode(iota)
+	// CrossingModeFirstTouch - Crossing is reported when any edge of bounding box touches the line for the first time (e.g. front bumper of long truck reaches the stop line)
+	CrossingModeFirstTouch
+	// CrossingModeFullyPassed - Crossing is reported when the whole bounding box has left the line on the opposite side
+	CrossingModeFullyPassed
+)
+
+// String - Returns text representation of CrossingMode
+func (mode CrossingMode) String() string {
+	switch mode {
+	case CrossingModePoint:
+		return "point"
+	case CrossingModeFirstTouch:
+		return "first touch"
+	case CrossingModeFullyPassed:
+		return "fully passed"
+	default:
+		return "unknown"
+	}
+}
+
+// rectCorners - Returns corners of rectangle in clockwise order (in image coordinates) starting from the top-left one
+func rectCorners(rect image.Rectangle) [4]image.Point {
+	return [4]image.Point{
+		rect.Min,
+		image.Pt(rect.Max.X, rect.Min.Y),
+		rect.Max,
+		image.Pt(rect.Min.X, rect.Max.Y),
+	}
+}
+
+// isRectIntersectsSegment - Checks if any edge of rectangle intersects segment start-end or if segment lies inside of rectangle
+func isRectIntersectsSegment(rect image.Rectangle, start, end image.Point) bool {
+	if start.In(rect) {
+		return true
+	}
+	corners := rectCorners(rect)
+	for i := range corners {
+		p, q := corners[i], corners[(i+1)%len(corners)]
+		if isIntersects(p.X, p.Y, q.X, q.Y, start.X, start.Y, end.X, end.Y) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRectSweptThroughSegment - Checks if movement of rectangle between two frames goes through segment start-end
+// It is needed when rectangle jumps over the line and touches it on neither of frames
+func isRectSweptThroughSegment(prev, curr image.Rectangle, start, end image.Point) bool {
+	prevCorners, currCorners := rectCorners(prev), rectCorners(curr)
+	for i := range prevCorners {
+		p, q := prevCorners[i], currCorners[i]
+		if isIntersects(p.X, p.Y, q.X, q.Y, start.X, start.Y, end.X, end.Y) {
+			return true
+		}
+	}
+	return false
+}
+
+// rectSideOfLine - Returns side of directed line where rectangle is located: 1 for the right side, -1 for the left one
+// Rectangle is assumed not to touch the line, so its center defines the side
+func rectSideOfLine(rect image.Rectangle, start, end image.Point) int {
+	if sideOfLine(start, end, NewAnchor(AnchorCenter).Point(rect)) >= 0 {
+		return 1
+	}
+	return -1
+}
+
+// sidesDirection - Returns direction of moving from one side of directed line to another one
+func sidesDirection(from, to int) CrossingDirection {
+	switch {
+	case from < 0 && to > 0:
+		return CrossingLeftToRight
+	case from > 0 && to < 0:
+		return CrossingRightToLeft
+	default:
+		return CrossingNone
+	}
+}
+
+// boxCrossingPoint - Returns fraction of frame interval and point where bounding box moving from prev to curr touches the line (first == true) or clears it (first == false)
+//
+// Moment of touching (clearing) is defined by the corner of bounding box which path crosses the line first (last). Point is the middle of
+// the part of line which is covered by the interpolated bounding box at that moment, e.g. middle of the front bumper for a vehicle driving straight into the line
+func (line *CountingLine) boxCrossingPoint(prev, curr image.Rectangle, first bool) (float64, image.Point) {
+	prevCorners, currCorners := rectCorners(prev), rectCorners(curr)
+	fraction, found := 1.0, false
+	for i := range prevCorners {
+		p, q := prevCorners[i], currCorners[i]
+		if !isIntersects(p.X, p.Y, q.X, q.Y, line.Start.X, line.Start.Y, line.End.X, line.End.Y) {
+			continue
+		}
+		t, _, ok := segmentsIntersection(p, q, line.Start, line.End)
+		if !ok {
+			continue
+		}
+		if !found || (first && t < fraction) || (!first && t > fraction) {
+			fraction, found = t, true
+		}
+	}
+	lerp := func(from, to int) float64 {
+		return float64(from) + fraction*float64(to-from)
+	}
+	minX, minY := lerp(prev.Min.X, curr.Min.X), lerp(prev.Min.Y, curr.Min.Y)
+	maxX, maxY := lerp(prev.Max.X, curr.Max.X), lerp(prev.Max.Y, curr.Max.Y)
+	if point, ok := clipSegmentMiddle(line.Start, line.End, minX, minY, maxX, maxY); ok {
+		return fraction, point
+	}
+	// Line touches neither interpolated nor current bounding box (possible only for lines shorter than box): previous one covers it then
+	if point, ok := clipSegmentMiddle(line.Start, line.End, float64(prev.Min.X), float64(prev.Min.Y), float64(prev.Max.X), float64(prev.Max.Y)); ok {
+		return fraction, point
+	}
+	return fraction, NewAnchor(AnchorCenter).Point(curr)
+}
+
+// clipSegmentMiddle - Returns middle of the part of segment start-end which lies inside of rectangle [minX; maxX] x [minY; maxY] (Liang-Barsky clipping)
+func clipSegmentMiddle(start, end image.Point, minX, minY, maxX, maxY float64) (image.Point, bool) {
+	dX, dY := float64(end.X-start.X), float64(end.Y-start.Y)
+	tMin, tMax := 0.0, 1.0
+	boundaries := [4][2]float64{
+		{-dX, float64(start.X) - minX},
+		{dX, maxX - float64(start.X)},
+		{-dY, float64(start.Y) - minY},
+		{dY, maxY - float64(start.Y)},
+	}
+	for _, boundary := range boundaries {
+		p, q := boundary[0], boundary[1]
+		if p == 0 {
+			if q < 0 {
+				return image.Point{}, false
+			}
+			continue
+		}
+		t := q / p
+		if p < 0 {
+			tMin = math.Max(tMin, t)
+		} else {
+			tMax = math.Min(tMax, t)
+		}
+	}
+	if tMin > tMax {
+		return image.Point{}, false
+	}
+	t := (tMin + tMax) / 2
+	return image.Pt(int(math.Round(float64(start.X)+t*dX)), int(math.Round(float64(start.Y)+t*dY))), true
+}
+
+// boxCrossingDirection - Returns direction of crossing the line by bounding box according to the line's Mode
+// Fraction of frame interval and point of crossing are evaluated for the edge of bounding box which has touched or cleared the line (see boxCrossingPoint)
+func (line *CountingLine) boxCrossingDirection(state *lineCrossingState, prev, curr image.Rectangle) (CrossingDirection, float64, image.Point) {
+	prevTouch := isRectIntersectsSegment(prev, line.Start, line.End)
+	currTouch := isRectIntersectsSegment(curr, line.Start, line.End)
+	if line.Mode == CrossingModeFirstTouch {
+		if prevTouch {
+			return CrossingNone, 0, image.Point{}
+		}
+		prevSide := rectSideOfLine(prev, line.Start, line.End)
+		direction := CrossingNone
+		if currTouch {
+			direction = sidesDirection(prevSide, -prevSide)
+		} else if currSide := rectSideOfLine(curr, line.Start, line.End); currSide != prevSide && isRectSweptThroughSegment(prev, curr, line.Start, line.End) {
+			direction = sidesDirection(prevSide, currSide)
+		}
+		if direction == CrossingNone {
+			return CrossingNone, 0, image.Point{}
+		}
+		fraction, point := line.boxCrossingPoint(prev, curr, true)
+		return direction, fraction, point
+	}
+	// CrossingModeFullyPassed
+	if state.boxSide == 0 && !prevTouch {
+		state.boxSide = rectSideOfLine(prev, line.Start, line.End)
+	}
+	if currTouch {
+		state.boxTouched = true
+		return CrossingNone, 0, image.Point{}
+	}
+	currSide := rectSideOfLine(curr, line.Start, line.End)
+	direction := CrossingNone
+	if state.boxSide != 0 && currSide != state.boxSide && (state.boxTouched || isRectSweptThroughSegment(prev, curr, line.Start, line.End)) {
+		direction = sidesDirection(state.boxSide, currSide)
+	}
+	state.boxSide = currSide
+	state.boxTouched = false
+	if direction == CrossingNone {
+		return CrossingNone, 0, image.Point{}
+	}
+	fraction, point := line.boxCrossingPoint(prev, curr, false)
+	return direction, fraction, point
+}
@@ -0,0 +1,113 @@
+package blob
+
+import (
+	"image"
+	"testing"
+	"time"
+)
+
+func TestCountingLineBoxModes(t *testing.T) {
+	// Long truck moves down through the stop line
+	modes := []struct {
+		mode    CrossingMode
+		correct int
+	}{
+		{CrossingModeFirstTouch, 7},
+		{CrossingModePoint, 10},
+		{CrossingModeFullyPassed, 13},
+	}
+	for _, m := range modes {
+		line, err := NewCountingLine("stop", image.Pt(0, 100), image.Pt(200, 100))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		line.Mode = m.mode
+		options := BlobOptions{ClassID: 1, ClassName: "truck", MaxPointsInTrack: 20}
+		b := NewSimpleBlobie(image.Rect(80, -150, 120, -30), &options)
+		frame := -1
+		direction := CrossingNone
+		for i := 1; i < 16; i++ {
+			b.Update(NewSimpleBlobie(image.Rect(80, -150+i*20, 120, -30+i*20), &options))
+			if crossing, ok := line.Check(b); ok {
+				frame = i
+				direction = crossing.Direction
+			}
+		}
+		if frame != m.correct || direction != CrossingForward {
+			t.Errorf("Mode '%s': crossing '%s' should be reported on frame %d, but got '%s' on frame %d", m.mode, CrossingForward, m.correct, direction, frame)
+		}
+	}
+}
+
+func TestCountingLineBoxModesJump(t *testing.T) {
+	// Box jumps over the line between two frames and touches it on neither of them
+	for _, mode := range []CrossingMode{CrossingModeFirstTouch, CrossingModeFullyPassed} {
+		line, err := NewCountingLine("stop", image.Pt(0, 100), image.Pt(200, 100))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		line.Mode = mode
+		options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10}
+		b := NewSimpleBlobie(image.Rect(80, 120, 120, 160), &options)
+		b.Update(NewSimpleBlobie(image.Rect(80, 40, 120, 80), &options))
+		if direction := line.CheckCrossing(b); direction != CrossingBackward {
+			t.Errorf("Mode '%s': direction should be '%s', but got '%s'", mode, CrossingBackward, direction)
+		}
+	}
+}
+
+func TestCountingLineFullyPassedReturn(t *testing.T) {
+	// Box touches the line and goes back: nothing should be reported
+	line, err := NewCountingLine("stop", image.Pt(0, 100), image.Pt(200, 100))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	line.Mode = CrossingModeFullyPassed
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10}
+	b := NewSimpleBlobie(image.Rect(80, 40, 120, 80), &options)
+	for _, y := range []int{60, 90, 110, 90, 60, 30} {
+		b.Update(NewSimpleBlobie(image.Rect(80, y-20, 120, y+20), &options))
+		if direction := line.CheckCrossing(b); direction != CrossingNone {
+			t.Errorf("No crossing should be reported, but got '%s'", direction)
+		}
+	}
+}
+
+func TestCountingLineBoxModesPoint(t *testing.T) {
+	// Truck moves down to the right: its front edge touches the line and its rear edge clears it in the middle of frame intervals,
+	// while the center is far from the line on both of these frames
+	modes := []struct {
+		mode         CrossingMode
+		frame        int
+		point        image.Point
+		interpolated time.Duration
+	}{
+		{CrossingModeFirstTouch, 7, image.Pt(103, 100), 6500 * time.Millisecond},
+		{CrossingModeFullyPassed, 13, image.Pt(115, 100), 12500 * time.Millisecond},
+	}
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, m := range modes {
+		line, err := NewCountingLine("stop", image.Pt(0, 100), image.Pt(400, 100))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		line.Mode = m.mode
+		options := BlobOptions{ClassID: 1, ClassName: "truck", MaxPointsInTrack: 20, Time: startTime}
+		b := NewSimpleBlobie(image.Rect(70, -150, 110, -30), &options)
+		for i := 1; i < 16; i++ {
+			options.Time = startTime.Add(time.Duration(i) * time.Second)
+			b.Update(NewSimpleBlobie(image.Rect(70+2*i, -150+i*20, 110+2*i, -30+i*20), &options))
+			crossing, ok := line.Check(b)
+			if !ok {
+				continue
+			}
+			if i != m.frame || crossing.Point != m.point || !crossing.InterpolatedTime.Equal(startTime.Add(m.interpolated)) {
+				t.Errorf("Mode '%s': crossing should be reported on frame %d at %v with interpolated time %v, but got frame %d at %v with interpolated time %v", m.mode, m.frame, m.point, startTime.Add(m.interpolated), i, crossing.Point, crossing.InterpolatedTime)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package blob
+
+// ClassGating - Mode of class-aware association of detections with existing blobs
+type ClassGating int
+
+const (
+	// ClassGatingNone - Class identifiers are ignored while matching (default)
+	ClassGatingNone = ClassGating(iota)
+	// ClassGatingStrict - Detection can be matched only to blob with equal class identifier
+	ClassGatingStrict
+	// ClassGatingMatrix - Detection can be matched to blob with equal class identifier or to blob with compatible class identifier (see ClassCompatibility)
+	ClassGatingMatrix
+)
+
+// ClassCompatibility - Set of class pairs which are allowed to be associated with each other
+type ClassCompatibility struct {
+	penalties map[[2]int]float64
+}
+
+// NewClassCompatibility - Constructor for ClassCompatibility. Only equal classes are compatible by default
+func NewClassCompatibility() *ClassCompatibility {
+	return &ClassCompatibility{
+		penalties: make(map[[2]int]float64),
+	}
+}
+
+// Allow - Allows association between two classes (in both directions)
+//
+// penalty - value which is added to association cost when classes differ.
+// It is measured in units of cost metric: pixels for CostDistance and overlap units for IoU-family metrics
+func (cc *ClassCompatibility) Allow(classA, classB int, penalty float64) {
+	cc.penalties[[2]int{classA, classB}] = penalty
+	cc.penalties[[2]int{classB, classA}] = penalty
+}
+
+// Penalty - Returns penalty for associating two classes and whether such association is allowed at all
+func (cc *ClassCompatibility) Penalty(classA, classB int) (float64, bool) {
+	if classA == classB {
+		return 0, true
+	}
+	if cc == nil {
+		return 0, false
+	}
+	penalty, ok := cc.penalties[[2]int{classA, classB}]
+	return penalty, ok
+}
+
+// classVoter - Keeps history of class identifiers observed for the blob, so blob's class is decided by majority vote
+type classVoter struct {
+	history    []int
+	names      map[int]string
+	maxHistory int
+}
+
+// newClassVoter - Constructor for classVoter
+func newClassVoter(classID int, className string, maxHistory int) classVoter {
+	return classVoter{
+		history:    []int{classID},
+		names:      map[int]string{classID: className},
+		maxHistory: maxHistory,
+	}
+}
+
+// vote - Appends observed class to history and returns class which wins majority vote
+// In case of tie current class is kept, so single misclassification does not flip the blob
+func (cv *classVoter) vote(currentID int, classID int, className string) (int, string) {
+	if cv.names == nil {
+		cv.names = make(map[int]string)
+	}
+	cv.names[classID] = className
+	cv.history = append(cv.history, classID)
+	if cv.maxHistory > 0 && len(cv.history) > cv.maxHistory {
+		cv.history = cv.history[1:]
+	}
+	counts := make(map[int]int)
+	for _, id := range cv.history {
+		counts[id]++
+	}
+	winnerID := currentID
+	for _, id := range cv.history {
+		if counts[id] > counts[winnerID] {
+			winnerID = id
+		}
+	}
+	return winnerID, cv.names[winnerID]
+}
@@ -0,0 +1,75 @@
+package blob
+
+import (
+	"image"
+	"testing"
+)
+
+func TestClassGating(t *testing.T) {
+	carOptions := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10}
+	truckOptions := BlobOptions{ClassID: 2, ClassName: "truck", MaxPointsInTrack: 10}
+	personOptions := BlobOptions{ClassID: 3, ClassName: "person", MaxPointsInTrack: 10}
+
+	rect := image.Rect(100, 100, 160, 140)
+	shifted := rect.Add(image.Pt(3, 3))
+
+	// Strict mode: person could not be merged into car
+	allblobies := NewBlobiesDefaults()
+	if err := allblobies.SetClassGating(ClassGatingStrict, nil); err != nil {
+		t.Error(err)
+		return
+	}
+	allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(rect, &carOptions)})
+	allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(shifted, &personOptions)})
+	if len(allblobies.Objects) != 2 {
+		t.Errorf("[Strict] Total number of blobs should be %d, but got %d", 2, len(allblobies.Objects))
+	}
+
+	// Matrix mode: car and truck are compatible, person is not compatible with any of them
+	compatibility := NewClassCompatibility()
+	compatibility.Allow(carOptions.ClassID, truckOptions.ClassID, 2.0)
+	allblobies = NewBlobiesDefaults()
+	if err := allblobies.SetClassGating(ClassGatingMatrix, nil); err == nil {
+		t.Error("[Matrix] Missing class compatibility should produce an error")
+	}
+	if err := allblobies.SetClassGating(ClassGatingMatrix, compatibility); err != nil {
+		t.Error(err)
+		return
+	}
+	allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(rect, &carOptions)})
+	allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(shifted, &truckOptions)})
+	if len(allblobies.Objects) != 1 {
+		t.Errorf("[Matrix] Total number of blobs should be %d, but got %d", 1, len(allblobies.Objects))
+	}
+	allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(shifted, &personOptions)})
+	if len(allblobies.Objects) != 2 {
+		t.Errorf("[Matrix] Total number of blobs should be %d, but got %d", 2, len(allblobies.Objects))
+	}
+	if penalty, ok := compatibility.Penalty(truckOptions.ClassID, carOptions.ClassID); !ok || penalty != 2.0 {
+		t.Errorf("[Matrix] Penalty should be %f (allowed), but got %f (allowed: %t)", 2.0, penalty, ok)
+	}
+}
+
+func TestClassVoting(t *testing.T) {
+	carOptions := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10}
+	truckOptions := BlobOptions{ClassID: 2, ClassName: "truck", MaxPointsInTrack: 10}
+
+	rect := image.Rect(100, 100, 160, 140)
+	b := NewSimpleBlobie(rect, &carOptions)
+	b.Update(NewSimpleBlobie(rect, &carOptions))
+	// Single misclassification should not flip the blob
+	b.Update(NewSimpleBlobie(rect, &truckOptions))
+	if b.GetClassID() != carOptions.ClassID {
+		t.Errorf("Class should be %d, but got %d", carOptions.ClassID, b.GetClassID())
+	}
+	// Tie should not flip the blob too
+	b.Update(NewSimpleBlobie(rect, &truckOptions))
+	if b.GetClassID() != carOptions.ClassID {
+		t.Errorf("Class should be %d, but got %d", carOptions.ClassID, b.GetClassID())
+	}
+	// Majority should flip the blob
+	b.Update(NewSimpleBlobie(rect, &truckOptions))
+	if b.GetClassID() != truckOptions.ClassID || b.GetClassName() != truckOptions.ClassName {
+		t.Errorf("Class should be %d (%s), but got %d (%s)", truckOptions.ClassID, truckOptions.ClassName, b.GetClassID(), b.GetClassName())
+	}
+}
@@ -0,0 +1,303 @@
+package blob
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// LineCrossing - Information about blob crossing the counting line
+type LineCrossing struct {
+	LineID string
+	BlobID uuid.UUID
+	// Direction - Side of the directed line (from Start to End) which blob has moved from and to
+	Direction CrossingDirection
+	// Time - Timestamp of frame on which crossing has been detected
+	Time time.Time
+	// InterpolatedTime - Sub-frame estimation of crossing moment: linear interpolation between timestamps of two consecutive track points.
+	// It equals to Time when timestamp of previous track point is not known
+	InterpolatedTime time.Time
+	// FrameInterval - Time passed between two consecutive track points which crossing has been detected on
+	FrameInterval time.Duration
+	// Point - Point where blob's track intersects the line (interpolated between two consecutive track points).
+	// For box modes it is the middle of the part of line covered by bounding box at the moment of touching or clearing the line
+	Point image.Point
+}
+
+// CrossingForward - Crossing of the counting line in its forward direction (from left side to right side as seen from Start to End)
+const CrossingForward = CrossingLeftToRight
+
+// CrossingBackward - Crossing of the counting line in its backward direction (from right side to left side as seen from Start to End)
+const CrossingBackward = CrossingRightToLeft
+
+// CrossingPolicy - Defines how many times the same blob could be counted on the line
+type CrossingPolicy int
+
+const (
+	// CrossingOncePerTrack - Blob is counted only once regardless of direction
+	CrossingOncePerTrack = CrossingPolicy(iota)
+	// CrossingOncePerDirection - Blob is counted at most once in each direction (e.g. U-turning vehicle is counted both as forward and backward)
+	CrossingOncePerDirection
+	// CrossingUnlimited - Blob is counted on each crossing. Crossings are suppressed until blob moves away from the line by Hysteresis after previous crossing
+	CrossingUnlimited
+)
+
+// String - Returns text representation of CrossingPolicy
+func (policy CrossingPolicy) String() string {
+	switch policy {
+	case CrossingOncePerTrack:
+		return "once per track"
+	case CrossingOncePerDirection:
+		return "once per direction"
+	case CrossingUnlimited:
+		return "unlimited"
+	default:
+		return "unknown"
+	}
+}
+
+// CountingLine - Named virtual line (segment) which blobs are counted on
+//
+// Line is directed: direction of crossing is defined by sides of the line as seen from Start to End (see CrossingDirection).
+// Crossing state is kept for each blob separately, so the same blob could be counted on several lines.
+// State is kept until Forget is called: use ForgetDeregistered to release it automatically when tracker deletes blob
+type CountingLine struct {
+	ID    string
+	Start image.Point
+	End   image.Point
+	// Policy - Re-crossing policy. Default is CrossingOncePerTrack
+	Policy CrossingPolicy
+	// Hysteresis - Distance (in pixels) from the line which blob has to reach after crossing before its next crossing is counted. Used by CrossingUnlimited policy only
+	Hysteresis float64
+	// Anchor - Point of blob's bounding box which is tested against the line. Default is center of bounding box
+	Anchor Anchor
+	// Mode - Defines what is tested against the line: anchor point (default) or the whole bounding box
+	Mode CrossingMode
+
+	states map[uuid.UUID]*lineCrossingState
+}
+
+// lineCrossingState - Crossing state of single blob on the line
+type lineCrossingState struct {
+	forward  bool
+	backward bool
+	// armed - false right after crossing until blob moves away from the line by hysteresis distance
+	armed bool
+	// boxSide - Side of the line where bounding box has been fully located last time (used by CrossingModeFullyPassed). Zero means unknown
+	boxSide int
+	// boxTouched - Whether bounding box has been touching the line since it was fully located on boxSide
+	boxTouched bool
+	// side - Last strict side of the line where tracked point has been located (used by CrossingModePoint). Zero means unknown
+	side int
+}
+
+// NewCountingLine - Constructor for CountingLine
+func NewCountingLine(id string, start, end image.Point) (*CountingLine, error) {
+	if start == end {
+		return nil, fmt.Errorf("start and end of line '%s' must be different points", id)
+	}
+	return &CountingLine{
+		ID:     id,
+		Start:  start,
+		End:    end,
+		Policy: CrossingOncePerTrack,
+		Mode:   CrossingModePoint,
+		states: make(map[uuid.UUID]*lineCrossingState),
+	}, nil
+}
+
+// CheckCrossing - Checks if the last segment of blob's track crosses the line and returns direction of crossing
+//
+// Both directions are checked at once, so there is no need to call it twice for bidirectional counting.
+// CrossingNone is returned when there is no crossing or when crossing is not allowed by the line's Policy
+func (line *CountingLine) CheckCrossing(b Blobie) CrossingDirection {
+	crossing, ok := line.Check(b)
+	if !ok {
+		return CrossingNone
+	}
+	return crossing.Direction
+}
+
+// Check - Checks if the last segment of blob's track (in terms of line's Anchor) crosses the line
+// For box modes (see CrossingMode) two last bounding boxes of blob are tested instead: Point and InterpolatedTime are evaluated then for the edge of bounding box which has touched or cleared the line
+//
+// Whether the same blob could be counted again is defined by the line's Policy
+func (line *CountingLine) Check(b Blobie) (LineCrossing, bool) {
+	track := line.Anchor.Track(b)
+	trackLen := len(track)
+	if trackLen < 2 {
+		return LineCrossing{}, false
+	}
+	prev, curr := track[trackLen-2], track[trackLen-1]
+	state, ok := line.states[b.GetID()]
+	if !ok {
+		state = &lineCrossingState{armed: true}
+		line.states[b.GetID()] = state
+	}
+	if !state.armed && line.distanceTo(curr) >= line.Hysteresis {
+		state.armed = true
+	}
+	var direction CrossingDirection
+	fraction, point := 1.0, curr
+	switch line.Mode {
+	case CrossingModeFirstTouch, CrossingModeFullyPassed:
+		rects := b.GetRectTrack()
+		if len(rects) < 2 {
+			return LineCrossing{}, false
+		}
+		trackLen = len(rects)
+		direction, fraction, point = line.boxCrossingDirection(state, rects[trackLen-2], rects[trackLen-1])
+	default:
+		direction = segmentCrossingDirection(&state.side, prev, curr, line.Start, line.End)
+		if t, intersection, ok := segmentsIntersection(prev, curr, line.Start, line.End); ok {
+			fraction, point = t, intersection
+		}
+	}
+	if direction == CrossingNone || !line.isAllowed(state, direction) {
+		return LineCrossing{}, false
+	}
+	if direction == CrossingForward {
+		state.forward = true
+	} else {
+		state.backward = true
+	}
+	state.armed = false
+	crossing := LineCrossing{
+		LineID:    line.ID,
+		BlobID:    b.GetID(),
+		Direction: direction,
+		Point:     point,
+	}
+	if timestamps := b.GetTimestamps(); len(timestamps) > 0 {
+		crossing.Time = timestamps[len(timestamps)-1]
+		crossing.InterpolatedTime = crossing.Time
+	}
+	if timestamps := b.GetTimestamps(); len(timestamps) == trackLen {
+		prevTime, currTime := timestamps[trackLen-2], timestamps[trackLen-1]
+		if _, ok := timeDeltaSeconds(prevTime, currTime); ok {
+			crossing.FrameInterval = currTime.Sub(prevTime)
+			crossing.InterpolatedTime = prevTime.Add(time.Duration(fraction * float64(crossing.FrameInterval)))
+		}
+	}
+	return crossing, true
+}
+
+// isAllowed - Checks if crossing in given direction could be counted according to the line's Policy
+func (line *CountingLine) isAllowed(state *lineCrossingState, direction CrossingDirection) bool {
+	switch line.Policy {
+	case CrossingOncePerDirection:
+		if direction == CrossingForward {
+			return !state.forward
+		}
+		return !state.backward
+	case CrossingUnlimited:
+		return state.armed
+	default:
+		return !state.forward && !state.backward
+	}
+}
+
+// distanceTo - Returns distance from point to the (infinite) line
+func (line *CountingLine) distanceTo(pt image.Point) float64 {
+	dx, dy := float64(line.End.X-line.Start.X), float64(line.End.Y-line.Start.Y)
+	cross := dx*float64(pt.Y-line.Start.Y) - dy*float64(pt.X-line.Start.X)
+	return math.Abs(cross) / math.Hypot(dx, dy)
+}
+
+// Forget - Removes crossing state of blob (e.g. when blob has been deregistered)
+func (line *CountingLine) Forget(id uuid.UUID) {
+	delete(line.states, id)
+}
+
+// ForgetDeregistered - Subscribes the line to deregistration events of tracker, so crossing state of deleted blobs is removed automatically
+func (line *CountingLine) ForgetDeregistered(bt *Blobies) {
+	bt.OnDeregistered(func(event TrackEvent) {
+		line.Forget(event.Blob.GetID())
+	})
+}
+
+// CountingLines - Registry of named counting lines
+type CountingLines struct {
+	lines map[string]*CountingLine
+}
+
+// NewCountingLines - Constructor for CountingLines
+func NewCountingLines() *CountingLines {
+	return &CountingLines{
+		lines: make(map[string]*CountingLine),
+	}
+}
+
+// Add - Adds line to the registry. Identifier of line must be unique
+func (registry *CountingLines) Add(line *CountingLine) error {
+	if _, ok := registry.lines[line.ID]; ok {
+		return fmt.Errorf("line '%s' already exists", line.ID)
+	}
+	registry.lines[line.ID] = line
+	return nil
+}
+
+// Get - Returns line by its identifier
+func (registry *CountingLines) Get(id string) (*CountingLine, bool) {
+	line, ok := registry.lines[id]
+	return line, ok
+}
+
+// Remove - Removes line from the registry
+func (registry *CountingLines) Remove(id string) {
+	delete(registry.lines, id)
+}
+
+// Check - Checks blob against every line in the registry. Crossings are sorted by line identifier
+func (registry *CountingLines) Check(b Blobie) []LineCrossing {
+	crossings := []LineCrossing{}
+	for _, id := range registry.ids() {
+		if crossing, ok := registry.lines[id].Check(b); ok {
+			crossings = append(crossings, crossing)
+		}
+	}
+	return crossings
+}
+
+// Forget - Removes crossing state of blob for every line in the registry (e.g. when blob has been deregistered)
+func (registry *CountingLines) Forget(id uuid.UUID) {
+	for _, line := range registry.lines {
+		line.Forget(id)
+	}
+}
+
+// ForgetDeregistered - Subscribes the registry to deregistration events of tracker, so crossing state of deleted blobs is removed from every line automatically
+// Lines added to the registry later are covered too
+func (registry *CountingLines) ForgetDeregistered(bt *Blobies) {
+	bt.OnDeregistered(func(event TrackEvent) {
+		registry.Forget(event.Blob.GetID())
+	})
+}
+
+func (registry *CountingLines) ids() []string {
+	ids := make([]string, 0, len(registry.lines))
+	for id := range registry.lines {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// segmentsIntersection - Returns intersection point of segments P1-Q1 and P2-Q2 and position of that point on the first segment (from 0 at P1 to 1 at Q1)
+// Third returned value is false for parallel segments
+func segmentsIntersection(p1, q1, p2, q2 image.Point) (float64, image.Point, bool) {
+	rX, rY := float64(q1.X-p1.X), float64(q1.Y-p1.Y)
+	sX, sY := float64(q2.X-p2.X), float64(q2.Y-p2.Y)
+	denominator := rX*sY - rY*sX
+	if denominator == 0 {
+		return 0, image.Point{}, false
+	}
+	t := (float64(p2.X-p1.X)*sY - float64(p2.Y-p1.Y)*sX) / denominator
+	t = math.Max(0, math.Min(1, t))
+	point := image.Pt(int(math.Round(float64(p1.X)+t*rX)), int(math.Round(float64(p1.Y)+t*rY)))
+	return t, point, true
+}
@@ -0,0 +1,161 @@
+package blob
+
+import (
+	"image"
+	"testing"
+	"time"
+)
+
+func TestCountingLines(t *testing.T) {
+	registry := NewCountingLines()
+	lineA, err := NewCountingLine("A", image.Pt(0, 50), image.Pt(200, 50))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	lineB, err := NewCountingLine("B", image.Pt(0, 110), image.Pt(200, 130))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err := registry.Add(lineA); err != nil {
+		t.Error(err)
+	}
+	if err := registry.Add(lineB); err != nil {
+		t.Error(err)
+	}
+	if err := registry.Add(lineA); err == nil {
+		t.Error("Adding line with the same identifier should produce an error")
+	}
+
+	allblobies := NewBlobiesDefaults()
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10}
+	crossings := []LineCrossing{}
+	for i := 0; i < 10; i++ {
+		options.Time = startTime.Add(time.Duration(i) * time.Second)
+		center := image.Pt(100, 10+i*20)
+		allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(image.Rect(center.X-20, center.Y-20, center.X+20, center.Y+20), &options)})
+		for _, b := range allblobies.Objects {
+			crossings = append(crossings, registry.Check(b)...)
+		}
+	}
+	if len(crossings) != 2 {
+		t.Errorf("Number of crossings should be %d, but got %d", 2, len(crossings))
+		return
+	}
+	correct := []LineCrossing{
+		// Blob touches line A on the 2nd second and leaves it to the other side on the 3rd one
+		LineCrossing{LineID: "A", Direction: CrossingLeftToRight, Time: startTime.Add(3 * time.Second), Point: image.Pt(100, 50)},
+		LineCrossing{LineID: "B", Direction: CrossingLeftToRight, Time: startTime.Add(6 * time.Second), Point: image.Pt(100, 120)},
+	}
+	for i := range correct {
+		if crossings[i].LineID != correct[i].LineID || crossings[i].Direction != correct[i].Direction || !crossings[i].Time.Equal(correct[i].Time) || crossings[i].Point != correct[i].Point {
+			t.Errorf("Crossing #%d should be %+v, but got %+v", i, correct[i], crossings[i])
+		}
+	}
+}
+
+func TestCountingLinePolicies(t *testing.T) {
+	// Vehicle drives down through the line, makes U-turn and drives back up through it.
+	// Then it jitters on the line before leaving downwards.
+	ys := []int{10, 30, 60, 90, 70, 40, 20, 45, 55, 48, 56, 90}
+	cases := []struct {
+		policy     CrossingPolicy
+		hysteresis float64
+		correct    []CrossingDirection
+	}{
+		{CrossingOncePerTrack, 0, []CrossingDirection{CrossingForward}},
+		{CrossingOncePerDirection, 0, []CrossingDirection{CrossingForward, CrossingBackward}},
+		{CrossingUnlimited, 0, []CrossingDirection{CrossingForward, CrossingBackward, CrossingForward, CrossingBackward, CrossingForward}},
+		{CrossingUnlimited, 20, []CrossingDirection{CrossingForward, CrossingBackward, CrossingForward}},
+	}
+	for _, c := range cases {
+		line, err := NewCountingLine("A", image.Pt(0, 50), image.Pt(200, 50))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		line.Policy = c.policy
+		line.Hysteresis = c.hysteresis
+		options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 20}
+		b := NewSimpleBlobie(image.Rect(90, ys[0]-10, 110, ys[0]+10), &options)
+		directions := []CrossingDirection{}
+		for _, y := range ys[1:] {
+			b.Update(NewSimpleBlobie(image.Rect(90, y-10, 110, y+10), &options))
+			if direction := line.CheckCrossing(b); direction != CrossingNone {
+				directions = append(directions, direction)
+			}
+		}
+		if len(directions) != len(c.correct) {
+			t.Errorf("Policy '%s' (hysteresis %.0f): crossings should be %v, but got %v", c.policy, c.hysteresis, c.correct, directions)
+			continue
+		}
+		for i := range c.correct {
+			if directions[i] != c.correct[i] {
+				t.Errorf("Policy '%s' (hysteresis %.0f): crossings should be %v, but got %v", c.policy, c.hysteresis, c.correct, directions)
+				break
+			}
+		}
+	}
+}
+
+func TestCountingLineRestOnLine(t *testing.T) {
+	line, err := NewCountingLine("A", image.Pt(0, 50), image.Pt(200, 50))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 3, Time: startTime}
+	b := NewSimpleBlobie(image.Rect(80, 20, 120, 60), &options)
+	// Vehicle stops on the line for longer than its track is kept and then leaves it downwards
+	ys := []int{50, 50, 50, 50, 50, 50, 60}
+	crossings := []LineCrossing{}
+	for i, y := range ys {
+		options.Time = startTime.Add(time.Duration(i+1) * time.Second)
+		if err := b.Update(NewSimpleBlobie(image.Rect(80, y-20, 120, y+20), &options)); err != nil {
+			t.Error(err)
+			return
+		}
+		if crossing, ok := line.Check(b); ok {
+			crossings = append(crossings, crossing)
+		}
+	}
+	if len(crossings) != 1 || crossings[0].Direction != CrossingForward || !crossings[0].Time.Equal(options.Time) {
+		t.Errorf("Single crossing '%s' at %s should be reported, but got %+v", CrossingForward, options.Time, crossings)
+	}
+}
+
+func TestCountingLinesForgetDeregistered(t *testing.T) {
+	registry := NewCountingLines()
+	line, err := NewCountingLine("A", image.Pt(0, 50), image.Pt(200, 50))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err := registry.Add(line); err != nil {
+		t.Error(err)
+		return
+	}
+	allblobies := NewBlobiesDefaults()
+	registry.ForgetDeregistered(allblobies)
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10}
+	for i := 0; i < 4; i++ {
+		options.Time = time.Date(2021, 1, 1, 0, 0, i, 0, time.UTC)
+		allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(image.Rect(80, 10+i*20, 120, 50+i*20), &options)})
+		for _, b := range allblobies.Objects {
+			registry.Check(b)
+		}
+	}
+	if len(line.states) != 1 {
+		t.Errorf("Line should keep state of %d blob, but got %d", 1, len(line.states))
+	}
+	// Let blob disappear
+	for len(allblobies.Objects) > 0 {
+		allblobies.MatchToExistingWithTime([]Blobie{}, options.Time)
+	}
+	if len(line.states) != 0 {
+		t.Errorf("State of deregistered blob should be removed, but got %d states", len(line.states))
+	}
+}
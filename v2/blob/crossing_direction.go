@@ -0,0 +1,138 @@
+package blob
+
+import (
+	"image"
+)
+
+// CrossingDirection - Direction of crossing the directed line (segment from its start to its end)
+//
+// Sides are defined as they are seen when looking from the start of the line to its end in image coordinates (Y-axis points down).
+// E.g. for line from (0, 0) to (100, 0) points with positive Y are on the right side
+type CrossingDirection int
+
+const (
+	// CrossingNone - Line has not been crossed
+	CrossingNone = CrossingDirection(iota)
+	// CrossingLeftToRight - Line has been crossed from its left side to its right side
+	CrossingLeftToRight
+	// CrossingRightToLeft - Line has been crossed from its right side to its left side
+	CrossingRightToLeft
+)
+
+// String - Returns text representation of CrossingDirection
+func (direction CrossingDirection) String() string {
+	switch direction {
+	case CrossingNone:
+		return "none"
+	case CrossingLeftToRight:
+		return "left to right"
+	case CrossingRightToLeft:
+		return "right to left"
+	default:
+		return "unknown"
+	}
+}
+
+// sideOfLine - Returns sign of cross product of vectors (start -> end) and (start -> pt)
+// Positive value means that point is on the right side of directed line (in image coordinates), negative - on the left side, zero - on the line
+func sideOfLine(start, end, pt image.Point) int {
+	cross := (end.X-start.X)*(pt.Y-start.Y) - (end.Y-start.Y)*(pt.X-start.X)
+	if cross > 0 {
+		return 1
+	}
+	if cross < 0 {
+		return -1
+	}
+	return 0
+}
+
+// segmentCrossingDirection - Returns direction of crossing the directed line segment start-end by movement from prev to curr
+//
+// lastSide keeps the last strict side of the line visited by the object (zero when it is unknown yet: then side of prev is used) and is updated
+// on every call. Point lying exactly on the line keeps the stored side, so crossing is reported only when object actually moves from one side
+// to another one: touching the line and moving back is not counted at all, while resting on the line for any number of frames does not lose crossing
+func segmentCrossingDirection(lastSide *int, prev, curr, start, end image.Point) CrossingDirection {
+	if *lastSide == 0 {
+		*lastSide = sideOfLine(start, end, prev)
+	}
+	currSide := sideOfLine(start, end, curr)
+	if currSide == 0 {
+		return CrossingNone
+	}
+	prevSide := *lastSide
+	*lastSide = currSide
+	if prevSide == currSide || prevSide == 0 || !isIntersects(prev.X, prev.Y, curr.X, curr.Y, start.X, start.Y, end.X, end.Y) {
+		return CrossingNone
+	}
+	if prevSide < 0 {
+		return CrossingLeftToRight
+	}
+	return CrossingRightToLeft
+}
+
+// trackObliqueLineCrossing - Returns direction of crossing the directed line by the last segment of track (shifted along the Y-axis)
+// lineSide keeps the last strict side of the line (see segmentCrossingDirection).
+// crossedLine is set to true when crossing happens and no more crossings are reported after that
+func trackObliqueLineCrossing(track []image.Point, isStillBeingTracked bool, crossedLine *bool, lineSide *int, startX, startY, endX, endY int, shift int) CrossingDirection {
+	trackLen := len(track)
+	if !isStillBeingTracked || trackLen < 2 || *crossedLine {
+		return CrossingNone
+	}
+	prev, curr := track[trackLen-2].Add(image.Pt(0, shift)), track[trackLen-1].Add(image.Pt(0, shift))
+	direction := segmentCrossingDirection(lineSide, prev, curr, image.Pt(startX, startY), image.Pt(endX, endY))
+	if direction != CrossingNone {
+		*crossedLine = true
+	}
+	return direction
+}
+
+// CheckObliqueLineCrossing - Check if blob crossed the directed line (from start to end) and returns direction of crossing [SimpleBlobie]
+// Blob's track of centroids is tested: use CheckObliqueLineCrossingWithAnchor for other points of bounding box
+func (b *SimpleBlobie) CheckObliqueLineCrossing(startX, startY, endX, endY int) CrossingDirection {
+	return trackObliqueLineCrossing(b.Track, b.isStillBeingTracked, &b.crossedLine, &b.lineSide, startX, startY, endX, endY, 0)
+}
+
+// CheckObliqueLineCrossingWithShift - Check if blob crossed the directed line (from start to end) with shift along the Y-axis and returns direction of crossing [SimpleBlobie]
+// Purpose of shifting: for "predicative" cropping when detection line very close to bottom of image
+func (b *SimpleBlobie) CheckObliqueLineCrossingWithShift(startX, startY, endX, endY int, shift int) CrossingDirection {
+	return trackObliqueLineCrossing(b.Track, b.isStillBeingTracked, &b.crossedLine, &b.lineSide, startX, startY, endX, endY, shift)
+}
+
+// CheckObliqueLineCrossingWithAnchor - Check if blob's track in terms of given anchor crossed the directed line (from start to end) and returns direction of crossing [SimpleBlobie]
+func (b *SimpleBlobie) CheckObliqueLineCrossingWithAnchor(anchor Anchor, startX, startY, endX, endY int) CrossingDirection {
+	return trackObliqueLineCrossing(anchor.Track(b), b.isStillBeingTracked, &b.crossedLine, &b.lineSide, startX, startY, endX, endY, 0)
+}
+
+// CheckObliqueLineCrossing - Check if blob crossed the directed line (from start to end) and returns direction of crossing [KalmanBlobie]
+// Blob's track of centroids is tested: use CheckObliqueLineCrossingWithAnchor for other points of bounding box
+func (b *KalmanBlobie) CheckObliqueLineCrossing(startX, startY, endX, endY int) CrossingDirection {
+	return trackObliqueLineCrossing(b.Track, b.isStillBeingTracked, &b.crossedLine, &b.lineSide, startX, startY, endX, endY, 0)
+}
+
+// CheckObliqueLineCrossingWithShift - Check if blob crossed the directed line (from start to end) with shift along the Y-axis and returns direction of crossing [KalmanBlobie]
+// Purpose of shifting: for "predicative" cropping when detection line very close to bottom of image
+func (b *KalmanBlobie) CheckObliqueLineCrossingWithShift(startX, startY, endX, endY int, shift int) CrossingDirection {
+	return trackObliqueLineCrossing(b.Track, b.isStillBeingTracked, &b.crossedLine, &b.lineSide, startX, startY, endX, endY, shift)
+}
+
+// CheckObliqueLineCrossingWithAnchor - Check if blob's track in terms of given anchor crossed the directed line (from start to end) and returns direction of crossing [KalmanBlobie]
+func (b *KalmanBlobie) CheckObliqueLineCrossingWithAnchor(anchor Anchor, startX, startY, endX, endY int) CrossingDirection {
+	return trackObliqueLineCrossing(anchor.Track(b), b.isStillBeingTracked, &b.crossedLine, &b.lineSide, startX, startY, endX, endY, 0)
+}
+
+// CheckObliqueLineCrossing - Check if blob crossed the directed line (from start to end) and returns direction of crossing [KalmanBBoxBlobie]
+// Blob's track of centroids is tested: use CheckObliqueLineCrossingWithAnchor for other points of bounding box
+func (b *KalmanBBoxBlobie) CheckObliqueLineCrossing(startX, startY, endX, endY int) CrossingDirection {
+	return trackObliqueLineCrossing(b.Track, b.isStillBeingTracked, &b.crossedLine, &b.lineSide, startX, startY, endX, endY, 0)
+}
+
+// CheckObliqueLineCrossingWithShift - Check if blob crossed the directed line (from start to end) with shift along the Y-axis and returns direction of crossing [KalmanBBoxBlobie]
+// Purpose of shifting: for "predicative" cropping when detection line very close to bottom of image
+func (b *KalmanBBoxBlobie) CheckObliqueLineCrossingWithShift(startX, startY, endX, endY int, shift int) CrossingDirection {
+	return trackObliqueLineCrossing(b.Track, b.isStillBeingTracked, &b.crossedLine, &b.lineSide, startX, startY, endX, endY, shift)
+}
+
+// CheckObliqueLineCrossingWithAnchor - Check if blob's track in terms of given anchor crossed the directed line (from start to end) and returns direction of crossing [KalmanBBoxBlobie]
+func (b *KalmanBBoxBlobie) CheckObliqueLineCrossingWithAnchor(anchor Anchor, startX, startY, endX, endY int) CrossingDirection {
+	return trackObliqueLineCrossing(anchor.Track(b), b.isStillBeingTracked, &b.crossedLine, &b.lineSide, startX, startY, endX, endY, 0)
+}
@@ -0,0 +1,111 @@
+package blob
+
+import (
+	"image"
+	"testing"
+)
+
+func TestSegmentCrossingDirection(t *testing.T) {
+	horizontal := [2]image.Point{image.Pt(0, 50), image.Pt(100, 50)}
+	cases := []struct {
+		name       string
+		track      []image.Point
+		start, end image.Point
+		correct    CrossingDirection
+	}{
+		// Horizontal line: moving down (to the right side of the line as seen from its start) and up
+		{"horizontal_down", []image.Point{image.Pt(50, 40), image.Pt(50, 60)}, horizontal[0], horizontal[1], CrossingLeftToRight},
+		{"horizontal_up", []image.Point{image.Pt(50, 60), image.Pt(50, 40)}, horizontal[0], horizontal[1], CrossingRightToLeft},
+		// Near-vertical line: Y-coordinate barely changes, but direction must be detected anyway
+		{"vertical_rightwards", []image.Point{image.Pt(40, 50), image.Pt(60, 50)}, image.Pt(50, 0), image.Pt(51, 100), CrossingRightToLeft},
+		{"vertical_leftwards", []image.Point{image.Pt(60, 50), image.Pt(40, 50)}, image.Pt(50, 0), image.Pt(51, 100), CrossingLeftToRight},
+		// Sideways motion with slight drift up against the line going up: Y-based check would say "from us"
+		{"vertical_sideways_drift", []image.Point{image.Pt(40, 52), image.Pt(60, 48)}, image.Pt(50, 100), image.Pt(50, 0), CrossingLeftToRight},
+		// Movement along the line and movement which does not reach the line
+		{"parallel", []image.Point{image.Pt(10, 40), image.Pt(90, 40)}, horizontal[0], horizontal[1], CrossingNone},
+		{"not_reached", []image.Point{image.Pt(50, 10), image.Pt(50, 40)}, horizontal[0], horizontal[1], CrossingNone},
+		// Touching the line is not a crossing yet: side is changed only when the point leaves the line
+		{"touch_from_left", []image.Point{image.Pt(50, 40), image.Pt(50, 50)}, horizontal[0], horizontal[1], CrossingNone},
+		{"touch_from_right", []image.Point{image.Pt(50, 60), image.Pt(50, 50)}, horizontal[0], horizontal[1], CrossingNone},
+		{"leave_to_right", []image.Point{image.Pt(50, 40), image.Pt(50, 50), image.Pt(50, 60)}, horizontal[0], horizontal[1], CrossingLeftToRight},
+		{"leave_to_left", []image.Point{image.Pt(50, 60), image.Pt(50, 50), image.Pt(50, 50), image.Pt(50, 40)}, horizontal[0], horizontal[1], CrossingRightToLeft},
+		{"leave_without_history", []image.Point{image.Pt(50, 50), image.Pt(50, 60)}, horizontal[0], horizontal[1], CrossingNone},
+		// Touching the line and moving back to the same side
+		{"touch_and_back_left", []image.Point{image.Pt(50, 40), image.Pt(50, 50), image.Pt(50, 40)}, horizontal[0], horizontal[1], CrossingNone},
+		{"touch_and_back_right", []image.Point{image.Pt(50, 60), image.Pt(50, 50), image.Pt(50, 60)}, horizontal[0], horizontal[1], CrossingNone},
+	}
+	for _, c := range cases {
+		// Track is fed frame by frame: only the last step is checked
+		side, direction := 0, CrossingNone
+		for i := 1; i < len(c.track); i++ {
+			direction = segmentCrossingDirection(&side, c.track[i-1], c.track[i], c.start, c.end)
+		}
+		if direction != c.correct {
+			t.Errorf("Case '%s': direction should be '%s', but got '%s'", c.name, c.correct, direction)
+		}
+	}
+}
+
+func TestCheckObliqueLineCrossingTouchAndBack(t *testing.T) {
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10}
+	for _, startY := range []int{40, 60} {
+		b := NewSimpleBlobie(image.Rect(40, startY-10, 60, startY+10), &options)
+		// Blob touches the horizontal line and comes back to the side it came from
+		for _, y := range []int{50, startY, 50, startY} {
+			if err := b.Update(NewSimpleBlobie(image.Rect(40, y-10, 60, y+10), &options)); err != nil {
+				t.Error(err)
+				return
+			}
+			if direction := b.CheckObliqueLineCrossing(0, 50, 100, 50); direction != CrossingNone {
+				t.Errorf("Blob started at Y=%d: no crossing should be reported, but got '%s' at Y=%d", startY, direction, y)
+			}
+		}
+	}
+}
+
+func TestCheckObliqueLineCrossing(t *testing.T) {
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10}
+	constructors := []func(rect image.Rectangle, options *BlobOptions) Blobie{
+		func(rect image.Rectangle, options *BlobOptions) Blobie { return NewSimpleBlobie(rect, options) },
+		func(rect image.Rectangle, options *BlobOptions) Blobie { return NewKalmanBlobie(rect, options) },
+		func(rect image.Rectangle, options *BlobOptions) Blobie { return NewKalmanBBoxBlobie(rect, options) },
+	}
+	for _, newBlob := range constructors {
+		b := newBlob(image.Rect(20, 40, 40, 60), &options)
+		// Blob moves rightwards (with slight drift up) through the near-vertical line going up
+		crossings := []CrossingDirection{}
+		for i := 1; i < 10; i++ {
+			x, y := 30+i*10, 50-i
+			if err := b.Update(newBlob(image.Rect(x-10, y-10, x+10, y+10), &options)); err != nil {
+				t.Error(err)
+				return
+			}
+			if direction := b.CheckObliqueLineCrossing(50, 100, 52, 0); direction != CrossingNone {
+				crossings = append(crossings, direction)
+			}
+		}
+		if len(crossings) != 1 || crossings[0] != CrossingLeftToRight {
+			t.Errorf("Blob %T: single crossing '%s' should be reported, but got %v", b, CrossingLeftToRight, crossings)
+		}
+	}
+}
+
+func TestCheckObliqueLineCrossingRestOnLine(t *testing.T) {
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 5}
+	b := NewSimpleBlobie(image.Rect(40, 30, 60, 50), &options)
+	// Blob stops on the line for longer than the track is kept and only then leaves it to the other side
+	ys := []int{50, 50, 50, 50, 50, 50, 50, 50, 60}
+	crossings := []CrossingDirection{}
+	for _, y := range ys {
+		if err := b.Update(NewSimpleBlobie(image.Rect(40, y-10, 60, y+10), &options)); err != nil {
+			t.Error(err)
+			return
+		}
+		if direction := b.CheckObliqueLineCrossing(0, 50, 100, 50); direction != CrossingNone {
+			crossings = append(crossings, direction)
+		}
+	}
+	if len(crossings) != 1 || crossings[0] != CrossingLeftToRight {
+		t.Errorf("Single crossing '%s' should be reported, but got %v", CrossingLeftToRight, crossings)
+	}
+}
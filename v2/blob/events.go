@@ -0,0 +1,93 @@
+package blob
+
+import (
+	"time"
+)
+
+// TerminationReason - Reason why track has been deregistered
+type TerminationReason int
+
+const (
+	// ReasonNone - Track has not been terminated
+	ReasonNone = TerminationReason(iota)
+	// ReasonMaxNoMatch - Track has not been matched for maxNoMatch frames in a row
+	ReasonMaxNoMatch
+	// ReasonNotConfirmed - Tentative track has not been confirmed within confirmation window
+	ReasonNotConfirmed
+)
+
+// String - Returns text representation of TerminationReason
+func (reason TerminationReason) String() string {
+	switch reason {
+	case ReasonNone:
+		return "none"
+	case ReasonMaxNoMatch:
+		return "max no match"
+	case ReasonNotConfirmed:
+		return "not confirmed"
+	default:
+		return "unknown"
+	}
+}
+
+// TrackEvent - Event of track lifecycle
+type TrackEvent struct {
+	// Blob - Blob which event is related to
+	Blob Blobie
+	// Time - Timestamp of frame on which event has happened
+	Time time.Time
+	// Reason - Reason of termination. It is ReasonNone for every event except deregistration
+	Reason TerminationReason
+}
+
+// TrackEventHandler - Function which is called when track event happens
+type TrackEventHandler func(event TrackEvent)
+
+// trackEventHandlers - Subscribers for each kind of track event
+type trackEventHandlers struct {
+	registered   []TrackEventHandler
+	confirmed    []TrackEventHandler
+	updated      []TrackEventHandler
+	lost         []TrackEventHandler
+	deregistered []TrackEventHandler
+}
+
+// OnRegistered - Subscribes handler to registration of new blobs
+func (bt *Blobies) OnRegistered(handler TrackEventHandler) {
+	bt.handlers.registered = append(bt.handlers.registered, handler)
+}
+
+// OnConfirmed - Subscribes handler to confirmation of tentative blobs
+func (bt *Blobies) OnConfirmed(handler TrackEventHandler) {
+	bt.handlers.confirmed = append(bt.handlers.confirmed, handler)
+}
+
+// OnUpdated - Subscribes handler to updates of existing blobs by matched detections
+func (bt *Blobies) OnUpdated(handler TrackEventHandler) {
+	bt.handlers.updated = append(bt.handlers.updated, handler)
+}
+
+// OnLost - Subscribes handler to blobs which have not been matched on the frame after being confirmed
+func (bt *Blobies) OnLost(handler TrackEventHandler) {
+	bt.handlers.lost = append(bt.handlers.lost, handler)
+}
+
+// OnDeregistered - Subscribes handler to deregistration of blobs
+func (bt *Blobies) OnDeregistered(handler TrackEventHandler) {
+	bt.handlers.deregistered = append(bt.handlers.deregistered, handler)
+}
+
+// emit - Calls every handler with event for given blob
+func (bt *Blobies) emit(handlers []TrackEventHandler, b Blobie, reason TerminationReason) {
+	if len(handlers) == 0 {
+		return
+	}
+	event := TrackEvent{
+		Blob:   b,
+		Time:   bt.frameTime,
+		Reason: reason,
+	}
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
@@ -0,0 +1,103 @@
+package blob
+
+import (
+	"image"
+	"testing"
+	"time"
+)
+
+func TestTrackEvents(t *testing.T) {
+	allblobies, err := NewBlobies(
+		WithMaxNoMatch(2),
+		WithConfirmation(2, 3),
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	counters := map[string]int{}
+	var lastDeregistration TrackEvent
+	allblobies.OnRegistered(func(event TrackEvent) { counters["registered"]++ })
+	allblobies.OnConfirmed(func(event TrackEvent) { counters["confirmed"]++ })
+	allblobies.OnUpdated(func(event TrackEvent) { counters["updated"]++ })
+	allblobies.OnLost(func(event TrackEvent) { counters["lost"]++ })
+	allblobies.OnDeregistered(func(event TrackEvent) {
+		counters["deregistered"]++
+		lastDeregistration = event
+	})
+
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	rect := image.Rect(100, 100, 140, 140)
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10}
+	for i := 0; i < 3; i++ {
+		options.Time = startTime.Add(time.Duration(i) * time.Second)
+		allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(rect.Add(image.Pt(i*2, 0)), &options)})
+	}
+	for i := 3; i < 5; i++ {
+		allblobies.MatchToExistingWithTime([]Blobie{}, startTime.Add(time.Duration(i)*time.Second))
+	}
+
+	correctCounters := map[string]int{
+		"registered":   1,
+		"confirmed":    1,
+		"updated":      2,
+		"lost":         1,
+		"deregistered": 1,
+	}
+	for name, correct := range correctCounters {
+		if counters[name] != correct {
+			t.Errorf("Number of '%s' events should be %d, but got %d", name, correct, counters[name])
+		}
+	}
+	if lastDeregistration.Reason != ReasonMaxNoMatch {
+		t.Errorf("Reason of deregistration should be '%s', but got '%s'", ReasonMaxNoMatch, lastDeregistration.Reason)
+	}
+	if correctTime := startTime.Add(4 * time.Second); !lastDeregistration.Time.Equal(correctTime) {
+		t.Errorf("Time of deregistration should be %s, but got %s", correctTime, lastDeregistration.Time)
+	}
+	if lastDeregistration.Blob == nil || lastDeregistration.Blob.GetState() != TrackDeleted {
+		t.Errorf("Deregistered blob should be in state '%s'", TrackDeleted)
+	}
+}
+
+func TestTrackEventsEmptyFrameTime(t *testing.T) {
+	allblobies, err := NewBlobies(WithMaxNoMatch(1))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var lost TrackEvent
+	allblobies.OnDeregistered(func(event TrackEvent) { lost = event })
+	// Offline processing: frames without detections should keep video time instead of wall-clock one
+	videoTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10, Time: videoTime}
+	allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(image.Rect(100, 100, 140, 140), &options)})
+	allblobies.MatchToExisting([]Blobie{})
+	if lost.Blob == nil || !lost.Time.Equal(videoTime) {
+		t.Errorf("Blob should be deregistered at video time %s, but got %+v", videoTime, lost)
+	}
+}
+
+func TestTrackUpdateError(t *testing.T) {
+	allblobies := NewBlobiesDefaults()
+	updated := 0
+	allblobies.OnUpdated(func(event TrackEvent) { updated++ })
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10}
+	rect := image.Rect(100, 100, 140, 140)
+	if err := allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(rect, &options)}); err != nil {
+		t.Error(err)
+		return
+	}
+	// Detection of another Blobie implementation can't update existing blob
+	if err := allblobies.MatchToExisting([]Blobie{NewKalmanBlobie(rect, &options)}); err == nil {
+		t.Error("Updating blob by detection of another type should produce an error")
+	}
+	if updated != 0 {
+		t.Errorf("No '%s' events should be emitted, but got %d", "updated", updated)
+	}
+	for _, b := range allblobies.Objects {
+		if b.NoMatchTimes() != 1 || b.GetState() != TrackLost {
+			t.Errorf("Blob should be considered not matched, but got %d frames without match and state '%s'", b.NoMatchTimes(), b.GetState())
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package blob
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/LdDl/gocv-blob/v2/calibration"
+	uuid "github.com/satori/go.uuid"
+)
+
+// HeadwayRecord - Headway between two successive blobs crossing the line in the same lane
+type HeadwayRecord struct {
+	LaneID      string
+	BlobID      uuid.UUID
+	ClassName   string
+	LeaderID    uuid.UUID
+	LeaderClass string
+	// Time - Moment of crossing the line by follower (interpolated, see LineCrossing.InterpolatedTime)
+	Time time.Time
+	// Headway - Time headway: time passed between crossings of leader and follower
+	Headway time.Duration
+	// HasSpaceHeadway - True when speed of follower in kilometers per hour has been known at the moment of crossing
+	HasSpaceHeadway bool
+	// SpaceHeadway - Space headway (front-to-front) in meters: distance which follower covers during time headway with its speed
+	SpaceHeadway float64
+	// HasSpaceGap - True when both space headway and length of leader (see HeadwayCollector.Calibration) have been known
+	HasSpaceGap bool
+	// SpaceGap - Gap (rear-to-front) in meters: space headway minus length of leader
+	SpaceGap float64
+}
+
+// HeadwayStats - Distribution of headways for single lane and time bin
+type HeadwayStats struct {
+	LaneID  string
+	TimeBin time.Time
+	// Count - Number of headways
+	Count int
+	Mean  time.Duration
+	Min   time.Duration
+	Max   time.Duration
+	// Percentiles - Percentiles of headways in the same order as requested
+	Percentiles []time.Duration
+	// SpaceHeadways - Number of headways which space headway is known for
+	SpaceHeadways int
+	// MeanSpaceHeadway - Mean space headway (front-to-front) in meters
+	MeanSpaceHeadway float64
+	// SpaceGaps - Number of headways which space gap is known for
+	SpaceGaps int
+	// MeanSpaceGap - Mean space gap (rear-to-front) in meters
+	MeanSpaceGap float64
+}
+
+// headwayCrossing - Crossing of the line stored by HeadwayCollector
+type headwayCrossing struct {
+	blobID    uuid.UUID
+	className string
+	time      time.Time
+	// kmh - Speed of blob at the moment of crossing. Negative value means unknown speed
+	kmh float64
+	// length - Length of blob in meters at the moment of crossing. Negative value means unknown length
+	length float64
+}
+
+// HeadwayCollector - Collector of time and space headways between successive blobs crossing the line
+//
+// Collector is bound to single line and direction of crossing: crossings of other lines or in opposite direction are rejected.
+// Crossings (see CountingLine.Check) are assigned to lanes by crossing point. If no lanes are added, then all crossings belong to single lane with empty identifier.
+// Crossings could be added in any order: they are sorted by interpolated time when headways are evaluated
+type HeadwayCollector struct {
+	// LineID - Identifier of the line which crossings are collected
+	LineID string
+	// Direction - Direction of crossings which are collected
+	Direction CrossingDirection
+	// BinSize - Duration of time bin. Zero value means that all headways belong to single bin
+	BinSize time.Duration
+	// MaxHeadway - Headways longer than this value (e.g. after period of empty road) are ignored. Zero value means no limit
+	MaxHeadway time.Duration
+	// Calibration - Homography which maps image points to ground points in meters. It is used to evaluate length of leader for space gap.
+	// If nil, then space gap is not evaluated
+	Calibration *calibration.Homography
+
+	laneIDs   []string
+	lanes     map[string]Polygon
+	crossings map[string][]headwayCrossing
+}
+
+// NewHeadwayCollector - Constructor for HeadwayCollector
+func NewHeadwayCollector(lineID string, direction CrossingDirection, binSize time.Duration) (*HeadwayCollector, error) {
+	if direction != CrossingLeftToRight && direction != CrossingRightToLeft {
+		return nil, fmt.Errorf("direction of crossings must be '%s' or '%s', but got '%s'", CrossingLeftToRight, CrossingRightToLeft, direction)
+	}
+	if binSize < 0 {
+		return nil, fmt.Errorf("bin size must be non-negative, but got %s", binSize)
+	}
+	return &HeadwayCollector{
+		LineID:    lineID,
+		Direction: direction,
+		BinSize:   binSize,
+		laneIDs:   []string{},
+		lanes:     make(map[string]Polygon),
+		crossings: make(map[string][]headwayCrossing),
+	}, nil
+}
+
+// AddLane - Adds lane defined by polygon. Identifier of lane must be unique
+func (c *HeadwayCollector) AddLane(id string, polygon Polygon) error {
+	if len(polygon) < 3 {
+		return fmt.Errorf("polygon of lane '%s' must have at least 3 vertices, but got %d", id, len(polygon))
+	}
+	if _, ok := c.lanes[id]; ok {
+		return fmt.Errorf("lane '%s' already exists", id)
+	}
+	c.laneIDs = append(c.laneIDs, id)
+	c.lanes[id] = polygon
+	return nil
+}
+
+// Add - Adds crossing of the line by blob. Blob is used for its class name, speed (see Blobie.GetSpeed) and length (see Calibration)
+// Returns false when crossing is related to another line or direction or when crossing point does not belong to any lane
+func (c *HeadwayCollector) Add(crossing LineCrossing, b Blobie) bool {
+	if crossing.LineID != c.LineID || crossing.Direction != c.Direction {
+		return false
+	}
+	laneID, ok := c.laneOf(crossing)
+	if !ok {
+		return false
+	}
+	stored := headwayCrossing{
+		blobID:    crossing.BlobID,
+		className: b.GetClassName(),
+		time:      crossing.InterpolatedTime,
+		kmh:       -1,
+		length:    -1,
+	}
+	if stored.time.IsZero() {
+		stored.time = crossing.Time
+	}
+	if speed, ok := b.GetSpeed(); ok && speed.Calibrated {
+		stored.kmh = speed.SmoothedKmPerHour
+	}
+	if c.Calibration != nil {
+		if length, ok := lengthAlongTrack(b, c.Calibration); ok {
+			stored.length = length
+		}
+	}
+	c.crossings[laneID] = append(c.crossings[laneID], stored)
+	return true
+}
+
+// lengthAlongTrack - Returns length in meters of blob's current bounding box measured along direction of travel
+// Box is cut by the line going through its center along the retained track (from its first point to the last one) and both ends of the cut are projected to the ground.
+// Second returned value is false when blob has not moved or when projection fails
+func lengthAlongTrack(b Blobie, h *calibration.Homography) (float64, bool) {
+	track := b.GetTrack()
+	if len(track) < 2 {
+		return 0, false
+	}
+	dx, dy := float64(track[len(track)-1].X-track[0].X), float64(track[len(track)-1].Y-track[0].Y)
+	if dx == 0 && dy == 0 {
+		return 0, false
+	}
+	rect := b.GetCurrentRect()
+	cx, cy := float64(rect.Min.X+rect.Max.X)/2, float64(rect.Min.Y+rect.Max.Y)/2
+	// Scale of direction vector to reach the border of the box from its center
+	scale := math.Inf(1)
+	if dx != 0 {
+		scale = math.Abs(float64(rect.Dx()) / 2 / dx)
+	}
+	if dy != 0 {
+		scale = math.Min(scale, math.Abs(float64(rect.Dy())/2/dy))
+	}
+	front, errFront := h.Project(calibration.Point{X: cx + scale*dx, Y: cy + scale*dy})
+	rear, errRear := h.Project(calibration.Point{X: cx - scale*dx, Y: cy - scale*dy})
+	if errFront != nil || errRear != nil {
+		return 0, false
+	}
+	return calibration.Distance(rear, front), true
+}
+
+// laneOf - Returns identifier of lane which crossing point belongs to. The first added lane is chosen when lanes overlap
+func (c *HeadwayCollector) laneOf(crossing LineCrossing) (string, bool) {
+	if len(c.laneIDs) == 0 {
+		return "", true
+	}
+	for _, id := range c.laneIDs {
+		if c.lanes[id].Contains(crossing.Point) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// Headways - Returns headways between successive crossings for each lane, sorted by lane identifier and by time
+// Successive crossings of the same blob (e.g. for CrossingUnlimited policy) are skipped
+func (c *HeadwayCollector) Headways() []HeadwayRecord {
+	laneIDs := make([]string, 0, len(c.crossings))
+	for id := range c.crossings {
+		laneIDs = append(laneIDs, id)
+	}
+	sort.Strings(laneIDs)
+	records := []HeadwayRecord{}
+	for _, laneID := range laneIDs {
+		crossings := make([]headwayCrossing, len(c.crossings[laneID]))
+		copy(crossings, c.crossings[laneID])
+		sort.SliceStable(crossings, func(i, j int) bool {
+			return crossings[i].time.Before(crossings[j].time)
+		})
+		for i := 1; i < len(crossings); i++ {
+			leader, follower := crossings[i-1], crossings[i]
+			if leader.blobID == follower.blobID {
+				continue
+			}
+			headway := follower.time.Sub(leader.time)
+			if c.MaxHeadway > 0 && headway > c.MaxHeadway {
+				continue
+			}
+			record := HeadwayRecord{
+				LaneID:      laneID,
+				BlobID:      follower.blobID,
+				ClassName:   follower.className,
+				LeaderID:    leader.blobID,
+				LeaderClass: leader.className,
+				Time:        follower.time,
+				Headway:     headway,
+			}
+			if follower.kmh >= 0 {
+				record.HasSpaceHeadway = true
+				record.SpaceHeadway = follower.kmh / msToKmh * headway.Seconds()
+				if leader.length >= 0 {
+					record.HasSpaceGap = true
+					record.SpaceGap = record.SpaceHeadway - leader.length
+				}
+			}
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// Stats - Returns distribution of headways for each lane and time bin (bin is defined by follower's crossing time)
+// percentiles - requested percentiles in [0; 100] (e.g. 15, 50, 85)
+func (c *HeadwayCollector) Stats(percentiles ...float64) ([]HeadwayStats, error) {
+	for _, p := range percentiles {
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("percentile must be in [0; 100], but got %f", p)
+		}
+	}
+	type statsKey struct {
+		laneID  string
+		timeBin time.Time
+	}
+	groups := make(map[statsKey][]HeadwayRecord)
+	keys := []statsKey{}
+	for _, record := range c.Headways() {
+		key := statsKey{laneID: record.LaneID, timeBin: timeBinStart(record.Time, c.BinSize)}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], record)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].laneID != keys[j].laneID {
+			return keys[i].laneID < keys[j].laneID
+		}
+		return keys[i].timeBin.Before(keys[j].timeBin)
+	})
+	stats := make([]HeadwayStats, 0, len(keys))
+	for _, key := range keys {
+		records := groups[key]
+		seconds := make([]float64, len(records))
+		sum, spaceSum, gapSum := 0.0, 0.0, 0.0
+		item := HeadwayStats{
+			LaneID:      key.laneID,
+			TimeBin:     key.timeBin,
+			Count:       len(records),
+			Percentiles: make([]time.Duration, len(percentiles)),
+		}
+		for i, record := range records {
+			seconds[i] = record.Headway.Seconds()
+			sum += seconds[i]
+			if record.HasSpaceHeadway {
+				item.SpaceHeadways++
+				spaceSum += record.SpaceHeadway
+			}
+			if record.HasSpaceGap {
+				item.SpaceGaps++
+				gapSum += record.SpaceGap
+			}
+		}
+		sort.Float64s(seconds)
+		item.Mean = secondsToDuration(sum / float64(len(seconds)))
+		item.Min = secondsToDuration(seconds[0])
+		item.Max = secondsToDuration(seconds[len(seconds)-1])
+		for i, p := range percentiles {
+			item.Percentiles[i] = secondsToDuration(percentile(seconds, p))
+		}
+		if item.SpaceHeadways > 0 {
+			item.MeanSpaceHeadway = spaceSum / float64(item.SpaceHeadways)
+		}
+		if item.SpaceGaps > 0 {
+			item.MeanSpaceGap = gapSum / float64(item.SpaceGaps)
+		}
+		stats = append(stats, item)
+	}
+	return stats, nil
+}
+
+// Reset - Removes all collected crossings (lanes are kept)
+func (c *HeadwayCollector) Reset() {
+	c.crossings = make(map[string][]headwayCrossing)
+}
+
+// percentile - Returns p-th percentile (p in [0; 100]) of sorted values using linear interpolation between closest ranks
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower])
+}
@@ -0,0 +1,120 @@
+package blob
+
+import (
+	"image"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/LdDl/gocv-blob/v2/calibration"
+)
+
+func TestHeadwayCollector(t *testing.T) {
+	if _, err := NewHeadwayCollector("stop", CrossingNone, 5*time.Second); err == nil {
+		t.Error("Collector without direction of crossings should produce an error")
+	}
+	collector, err := NewHeadwayCollector("stop", CrossingForward, 5*time.Second)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err := collector.AddLane("left", Polygon{image.Pt(0, 0), image.Pt(99, 0), image.Pt(99, 200), image.Pt(0, 200)}); err != nil {
+		t.Error(err)
+	}
+	if err := collector.AddLane("right", Polygon{image.Pt(100, 0), image.Pt(200, 0), image.Pt(200, 200), image.Pt(100, 200)}); err != nil {
+		t.Error(err)
+	}
+	// Scale: 10 pixels per meter
+	h, err := calibration.NewHomographyFromMatrix([9]float64{0.1, 0, 0, 0, 0.1, 0, 0, 0, 1})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	collector.Calibration = h
+
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	crossings := []struct {
+		x       int
+		seconds float64
+	}{
+		// Crossings are added out of order on purpose
+		{50, 0}, {150, 1}, {50, 5}, {50, 2}, {150, 4}, {50, 6}, {300, 3},
+	}
+	added := 0
+	for _, crossing := range crossings {
+		// Blob moves 100 pixels per second (10 meters per second)
+		b := newMovingBlob(startTime, "car", 20, []image.Point{{crossing.x, 60}, {crossing.x, 70}, {crossing.x, 80}, {crossing.x, 90}, {crossing.x, 100}})
+		b.SetSpeedOptions(&SpeedOptions{Window: 5, Calibration: h})
+		at := startTime.Add(time.Duration(crossing.seconds * float64(time.Second)))
+		if collector.Add(LineCrossing{LineID: "stop", BlobID: b.GetID(), Direction: CrossingForward, Time: at, InterpolatedTime: at, Point: image.Pt(crossing.x, 100)}, b) {
+			added++
+		}
+	}
+	if added != 6 {
+		t.Errorf("Crossing outside of lanes should be ignored: number of added crossings should be %d, but got %d", 6, added)
+	}
+	// Crossings of another line, in opposite direction and repeated crossing of the same blob
+	b := newMovingBlob(startTime, "car", 20, []image.Point{{50, 60}, {50, 70}, {50, 80}, {50, 90}, {50, 100}})
+	at := startTime.Add(7 * time.Second)
+	if collector.Add(LineCrossing{LineID: "other", BlobID: b.GetID(), Direction: CrossingForward, Time: at, InterpolatedTime: at, Point: image.Pt(50, 100)}, b) {
+		t.Error("Crossing of another line should be rejected")
+	}
+	if collector.Add(LineCrossing{LineID: "stop", BlobID: b.GetID(), Direction: CrossingBackward, Time: at, InterpolatedTime: at, Point: image.Pt(50, 100)}, b) {
+		t.Error("Crossing in opposite direction should be rejected")
+	}
+	for _, seconds := range []float64{7, 7.5} {
+		at := startTime.Add(time.Duration(seconds * float64(time.Second)))
+		collector.Add(LineCrossing{LineID: "stop", BlobID: b.GetID(), Direction: CrossingForward, Time: at, InterpolatedTime: at, Point: image.Pt(50, 100)}, b)
+	}
+
+	headways := collector.Headways()
+	// Headway between two crossings of the same blob is not evaluated
+	correctHeadways := []time.Duration{2 * time.Second, 3 * time.Second, time.Second, time.Second, 3 * time.Second}
+	if len(headways) != len(correctHeadways) {
+		t.Errorf("Number of headways should be %d, but got %d", len(correctHeadways), len(headways))
+		return
+	}
+	for i := range correctHeadways {
+		if headways[i].Headway != correctHeadways[i] {
+			t.Errorf("Headway #%d should be %s, but got %s", i, correctHeadways[i], headways[i].Headway)
+		}
+	}
+	if !headways[0].HasSpaceHeadway || math.Abs(headways[0].SpaceHeadway-20) > 1e-6 {
+		t.Errorf("Space headway should be %f m, but got %f", 20.0, headways[0].SpaceHeadway)
+	}
+	// Leader is 20 pixels (2 meters) long along direction of travel
+	if !headways[0].HasSpaceGap || math.Abs(headways[0].SpaceGap-18) > 1e-6 {
+		t.Errorf("Space gap should be %f m, but got %f", 18.0, headways[0].SpaceGap)
+	}
+
+	stats, err := collector.Stats(50, 85)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	correctStats := []HeadwayStats{
+		{LaneID: "left", TimeBin: startTime, Count: 1, Mean: 2 * time.Second, Min: 2 * time.Second, Max: 2 * time.Second},
+		{LaneID: "left", TimeBin: startTime.Add(5 * time.Second), Count: 3, Mean: 5 * time.Second / 3, Min: time.Second, Max: 3 * time.Second},
+		{LaneID: "right", TimeBin: startTime, Count: 1, Mean: 3 * time.Second, Min: 3 * time.Second, Max: 3 * time.Second},
+	}
+	if len(stats) != len(correctStats) {
+		t.Errorf("Number of stats items should be %d, but got %d", len(correctStats), len(stats))
+		return
+	}
+	for i, correct := range correctStats {
+		got := stats[i]
+		if got.LaneID != correct.LaneID || !got.TimeBin.Equal(correct.TimeBin) || got.Count != correct.Count || got.Mean != correct.Mean || got.Min != correct.Min || got.Max != correct.Max {
+			t.Errorf("Stats item #%d should be %+v, but got %+v", i, correct, got)
+		}
+	}
+	if stats[1].Percentiles[0] != time.Second || stats[1].Percentiles[1] != 2400*time.Millisecond {
+		t.Errorf("Percentiles should be %v, but got %v", []time.Duration{time.Second, 2400 * time.Millisecond}, stats[1].Percentiles)
+	}
+	// Speed of the last blob is not calibrated, so it has neither space headway nor space gap
+	if stats[1].SpaceGaps != 2 || math.Abs(stats[1].MeanSpaceHeadway-20) > 1e-6 || math.Abs(stats[1].MeanSpaceGap-18) > 1e-6 {
+		t.Errorf("Mean space headway and gap should be %f m and %f m over %d headways, but got %f m and %f m over %d", 20.0, 18.0, 2, stats[1].MeanSpaceHeadway, stats[1].MeanSpaceGap, stats[1].SpaceGaps)
+	}
+	if _, err := collector.Stats(120); err == nil {
+		t.Error("Percentile out of [0; 100] should produce an error")
+	}
+}
@@ -0,0 +1,171 @@
+package blob
+
+import (
+	"math"
+)
+
+// AssignmentResult - Result of solving assignment problem for cost matrix
+type AssignmentResult struct {
+	// Matches - Pairs of (row, column) indices which have been assigned to each other
+	Matches [][2]int
+	// UnmatchedRows - Row indices which have not been assigned to any column
+	UnmatchedRows []int
+	// UnmatchedCols - Column indices which have not been assigned to any row
+	UnmatchedCols []int
+}
+
+// SolveAssignment - Solves rectangular linear assignment problem via Hungarian algorithm (Kuhn-Munkres with Jonker-Volgenant style potentials)
+//
+// Each row is assigned to at most one column and each column is assigned to at most one row, so that total cost is minimal.
+// Cells containing math.Inf(1) (or NaN) are treated as forbidden pairs: such pairs are never reported in Matches,
+// corresponding row and column are reported as unmatched instead.
+func SolveAssignment(cost [][]float64) AssignmentResult {
+	rows := len(cost)
+	cols := 0
+	if rows > 0 {
+		cols = len(cost[0])
+	}
+	result := AssignmentResult{
+		Matches:       [][2]int{},
+		UnmatchedRows: []int{},
+		UnmatchedCols: []int{},
+	}
+	if rows == 0 || cols == 0 {
+		for i := 0; i < rows; i++ {
+			result.UnmatchedRows = append(result.UnmatchedRows, i)
+		}
+		for j := 0; j < cols; j++ {
+			result.UnmatchedCols = append(result.UnmatchedCols, j)
+		}
+		return result
+	}
+
+	// Replace forbidden cells with big finite value, so algorithm always converges
+	maxFinite := 0.0
+	for i := range cost {
+		for j := range cost[i] {
+			if isForbiddenCost(cost[i][j]) {
+				continue
+			}
+			maxFinite = math.Max(maxFinite, math.Abs(cost[i][j]))
+		}
+	}
+	forbidden := (maxFinite + 1.0) * float64(rows+cols)
+
+	// Algorithm below expects number of rows to be less or equal than number of columns. So transpose if needed
+	transposed := rows > cols
+	n, m := rows, cols
+	if transposed {
+		n, m = cols, rows
+	}
+	a := make([][]float64, n)
+	for i := 0; i < n; i++ {
+		a[i] = make([]float64, m)
+		for j := 0; j < m; j++ {
+			value := 0.0
+			if transposed {
+				value = cost[j][i]
+			} else {
+				value = cost[i][j]
+			}
+			if isForbiddenCost(value) {
+				value = forbidden
+			}
+			a[i][j] = value
+		}
+	}
+
+	rowForCol := hungarian(a, n, m)
+
+	rowAssigned := make([]bool, rows)
+	colAssigned := make([]bool, cols)
+	for j := 1; j <= m; j++ {
+		if rowForCol[j] == 0 {
+			continue
+		}
+		row, col := rowForCol[j]-1, j-1
+		if transposed {
+			row, col = col, row
+		}
+		if isForbiddenCost(cost[row][col]) {
+			continue
+		}
+		rowAssigned[row] = true
+		colAssigned[col] = true
+		result.Matches = append(result.Matches, [2]int{row, col})
+	}
+	for i := range rowAssigned {
+		if !rowAssigned[i] {
+			result.UnmatchedRows = append(result.UnmatchedRows, i)
+		}
+	}
+	for j := range colAssigned {
+		if !colAssigned[j] {
+			result.UnmatchedCols = append(result.UnmatchedCols, j)
+		}
+	}
+	return result
+}
+
+// hungarian - Solves assignment problem for n x m matrix where n <= m
+// Returns 1-based indices of rows assigned to each 1-based column (0 means that column is free)
+func hungarian(a [][]float64, n, m int) []int {
+	u := make([]float64, n+1)
+	v := make([]float64, m+1)
+	p := make([]int, m+1)
+	way := make([]int, m+1)
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, m+1)
+		used := make([]bool, m+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+		for {
+			used[j0] = true
+			i0 := p[j0]
+			delta := math.Inf(1)
+			j1 := 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				cur := a[i0-1][j-1] - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+		for {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+			if j0 == 0 {
+				break
+			}
+		}
+	}
+	return p
+}
+
+func isForbiddenCost(value float64) bool {
+	return math.IsInf(value, 1) || math.IsNaN(value)
+}
@@ -0,0 +1,87 @@
+package blob
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestSolveAssignment(t *testing.T) {
+	inf := math.Inf(1)
+	cost := [][]float64{
+		[]float64{4, 1, 3},
+		[]float64{2, 0, 5},
+		[]float64{3, 2, 2},
+	}
+	result := SolveAssignment(cost)
+	correctMatches := map[int]int{0: 1, 1: 0, 2: 2}
+	if len(result.Matches) != len(correctMatches) {
+		t.Errorf("Number of matches should be %d, but got %d", len(correctMatches), len(result.Matches))
+	}
+	for _, match := range result.Matches {
+		if correctMatches[match[0]] != match[1] {
+			t.Errorf("Row %d should be assigned to column %d, but got %d", match[0], correctMatches[match[0]], match[1])
+		}
+	}
+
+	// More rows than columns, one of cells is forbidden
+	cost = [][]float64{
+		[]float64{1, inf},
+		[]float64{inf, inf},
+		[]float64{2, 3},
+	}
+	result = SolveAssignment(cost)
+	correctMatches = map[int]int{0: 0, 2: 1}
+	if len(result.Matches) != len(correctMatches) {
+		t.Errorf("Number of matches should be %d, but got %d", len(correctMatches), len(result.Matches))
+	}
+	for _, match := range result.Matches {
+		if correctMatches[match[0]] != match[1] {
+			t.Errorf("Row %d should be assigned to column %d, but got %d", match[0], correctMatches[match[0]], match[1])
+		}
+	}
+	if len(result.UnmatchedRows) != 1 || result.UnmatchedRows[0] != 1 {
+		t.Errorf("Unmatched rows should be [1], but got %v", result.UnmatchedRows)
+	}
+	if len(result.UnmatchedCols) != 0 {
+		t.Errorf("Unmatched columns should be empty, but got %v", result.UnmatchedCols)
+	}
+
+	// Empty matrix
+	result = SolveAssignment([][]float64{})
+	if len(result.Matches) != 0 || len(result.UnmatchedRows) != 0 || len(result.UnmatchedCols) != 0 {
+		t.Errorf("Empty matrix should produce empty result, but got %v", result)
+	}
+}
+
+func TestHungarianArrayTracker(t *testing.T) {
+	allblobies := NewBlobiesDefaults()
+	allblobies.SetMatchingStrategy(MatchingHungarian)
+
+	rectHalfSize := 20
+	makeRect := func(x, y int) image.Rectangle {
+		return image.Rect(x-rectHalfSize, y-rectHalfSize, x+rectHalfSize, y+rectHalfSize)
+	}
+
+	// Two objects close to each other
+	allblobies.MatchToExisting([]Blobie{
+		NewSimpleBlobie(makeRect(100, 100), nil),
+		NewSimpleBlobie(makeRect(130, 100), nil),
+	})
+	if len(allblobies.Objects) != 2 {
+		t.Errorf("Total number of blobs should be %d, but got %d", 2, len(allblobies.Objects))
+	}
+	// Both detections are closer to the first object, but each object should get exactly one of them
+	allblobies.MatchToExisting([]Blobie{
+		NewSimpleBlobie(makeRect(105, 100), nil),
+		NewSimpleBlobie(makeRect(112, 100), nil),
+	})
+	if len(allblobies.Objects) != 2 {
+		t.Errorf("Total number of blobs should be %d, but got %d", 2, len(allblobies.Objects))
+	}
+	for _, b := range allblobies.Objects {
+		if len(b.GetTrack()) != 2 {
+			t.Errorf("Each blob should have track of length %d, but got %d", 2, len(b.GetTrack()))
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package blob
+
+import (
+	"image"
+	"math"
+)
+
+// CostMetric - Metric which is used for evaluating cost of associating detection with existing blob
+type CostMetric int
+
+const (
+	// CostDistance - Euclidean distance between centers (default)
+	CostDistance = CostMetric(iota)
+	// CostIoU - 1 - Intersection over Union of bounding boxes
+	CostIoU
+	// CostGIoU - 1 - Generalized Intersection over Union of bounding boxes
+	CostGIoU
+	// CostDIoU - 1 - Distance Intersection over Union of bounding boxes
+	CostDIoU
+	// CostCIoU - 1 - Complete Intersection over Union of bounding boxes
+	CostCIoU
+)
+
+// IoU - Returns Intersection over Union of two rectangles. Value is in [0; 1]
+func IoU(r1, r2 image.Rectangle) float64 {
+	intersection := rectArea(r1.Intersect(r2))
+	union := rectArea(r1) + rectArea(r2) - intersection
+	if union <= 0 {
+		return 0
+	}
+	return intersection / union
+}
+
+// GIoU - Returns Generalized Intersection over Union of two rectangles. Value is in [-1; 1]
+// For more ref. see: https://arxiv.org/abs/1902.09630
+func GIoU(r1, r2 image.Rectangle) float64 {
+	intersection := rectArea(r1.Intersect(r2))
+	union := rectArea(r1) + rectArea(r2) - intersection
+	enclosing := rectArea(r1.Union(r2))
+	if union <= 0 || enclosing <= 0 {
+		return 0
+	}
+	return intersection/union - (enclosing-union)/enclosing
+}
+
+// DIoU - Returns Distance Intersection over Union of two rectangles. Value is in [-1; 1]
+// For more ref. see: https://arxiv.org/abs/1911.08287
+func DIoU(r1, r2 image.Rectangle) float64 {
+	return IoU(r1, r2) - centersDistancePenalty(r1, r2)
+}
+
+// CIoU - Returns Complete Intersection over Union of two rectangles. Value is in [-1; 1]
+// For more ref. see: https://arxiv.org/abs/1911.08287
+func CIoU(r1, r2 image.Rectangle) float64 {
+	iou := IoU(r1, r2)
+	w1, h1 := float64(r1.Dx()), float64(r1.Dy())
+	w2, h2 := float64(r2.Dx()), float64(r2.Dy())
+	v := 0.0
+	if h1 > 0 && h2 > 0 {
+		v = 4 / (math.Pi * math.Pi) * math.Pow(math.Atan(w1/h1)-math.Atan(w2/h2), 2)
+	}
+	alpha := 0.0
+	if (1-iou)+v > 0 {
+		alpha = v / ((1 - iou) + v)
+	}
+	return iou - centersDistancePenalty(r1, r2) - alpha*v
+}
+
+// overlapSimilarity - Returns similarity of two rectangles for given metric (IoU-family only)
+func overlapSimilarity(metric CostMetric, r1, r2 image.Rectangle) float64 {
+	switch metric {
+	case CostGIoU:
+		return GIoU(r1, r2)
+	case CostDIoU:
+		return DIoU(r1, r2)
+	case CostCIoU:
+		return CIoU(r1, r2)
+	default:
+		return IoU(r1, r2)
+	}
+}
+
+// centersDistancePenalty - Returns squared distance between centers normalized by squared diagonal of enclosing rectangle
+func centersDistancePenalty(r1, r2 image.Rectangle) float64 {
+	enclosing := r1.Union(r2)
+	diagonalSquared := math.Pow(float64(enclosing.Dx()), 2) + math.Pow(float64(enclosing.Dy()), 2)
+	if diagonalSquared <= 0 {
+		return 0
+	}
+	c1x, c1y := float64(r1.Min.X+r1.Max.X)/2.0, float64(r1.Min.Y+r1.Max.Y)/2.0
+	c2x, c2y := float64(r2.Min.X+r2.Max.X)/2.0, float64(r2.Min.Y+r2.Max.Y)/2.0
+	return (math.Pow(c1x-c2x, 2) + math.Pow(c1y-c2y, 2)) / diagonalSquared
+}
+
+// rectPredictor - Blob which is able to predict its bounding box, not only its center (e.g. KalmanBBoxBlobie)
+type rectPredictor interface {
+	GetPredictedNextRect() image.Rectangle
+}
+
+// predictedRect - Returns predicted rectangle of blob if it is supported, otherwise returns current rectangle of blob shifted to its predicted position
+func predictedRect(b Blobie) image.Rectangle {
+	if predictor, ok := b.(rectPredictor); ok {
+		return predictor.GetPredictedNextRect()
+	}
+	return b.GetCurrentRect().Add(b.GetPredictedNextPosition().Sub(b.GetCenter()))
+}
+
+func rectArea(r image.Rectangle) float64 {
+	if r.Empty() {
+		return 0
+	}
+	return float64(r.Dx()) * float64(r.Dy())
+}
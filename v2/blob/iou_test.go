@@ -0,0 +1,77 @@
+package blob
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestIoU(t *testing.T) {
+	eps := 1e-6
+	r1 := image.Rect(0, 0, 10, 10)
+	r2 := image.Rect(5, 0, 15, 10)
+	r3 := image.Rect(20, 0, 30, 10)
+
+	if iou := IoU(r1, r1); math.Abs(iou-1.0) > eps {
+		t.Errorf("IoU of the same rectangles should be %f, but got %f", 1.0, iou)
+	}
+	if iou := IoU(r1, r2); math.Abs(iou-1.0/3.0) > eps {
+		t.Errorf("IoU should be %f, but got %f", 1.0/3.0, iou)
+	}
+	if iou := IoU(r1, r3); iou != 0 {
+		t.Errorf("IoU of non-overlapping rectangles should be %f, but got %f", 0.0, iou)
+	}
+	// Enclosing rectangle is 30x10, union is 200
+	if giou := GIoU(r1, r3); math.Abs(giou-(-1.0/3.0)) > eps {
+		t.Errorf("GIoU should be %f, but got %f", -1.0/3.0, giou)
+	}
+	// Distance between centers is 5, diagonal of enclosing rectangle is sqrt(15^2 + 10^2)
+	correctDIoU := 1.0/3.0 - 25.0/325.0
+	if diou := DIoU(r1, r2); math.Abs(diou-correctDIoU) > eps {
+		t.Errorf("DIoU should be %f, but got %f", correctDIoU, diou)
+	}
+	// Aspect ratios are equal, so CIoU should be equal to DIoU
+	if ciou := CIoU(r1, r2); math.Abs(ciou-correctDIoU) > eps {
+		t.Errorf("CIoU should be %f, but got %f", correctDIoU, ciou)
+	}
+}
+
+func TestIoUArrayTracker(t *testing.T) {
+	allblobies := NewBlobiesDefaults()
+	if err := allblobies.SetCostMetric(CostIoU); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := allblobies.SetMinOverlap(0.3); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := allblobies.SetMinOverlap(1.5); err == nil {
+		t.Error("Min overlap out of [-1; 1] should produce an error")
+	}
+	if err := allblobies.SetCostMetric(CostMetric(100)); err == nil {
+		t.Error("Unknown cost metric should produce an error")
+	}
+
+	// Bus and motorbike next to each other
+	bus := image.Rect(100, 100, 400, 220)
+	motorbike := image.Rect(380, 180, 410, 240)
+	allblobies.MatchToExisting([]Blobie{
+		NewSimpleBlobie(bus, nil),
+		NewSimpleBlobie(motorbike, nil),
+	})
+	for i := 1; i < 5; i++ {
+		allblobies.MatchToExisting([]Blobie{
+			NewSimpleBlobie(bus.Add(image.Pt(i*5, 0)), nil),
+			NewSimpleBlobie(motorbike.Add(image.Pt(i*3, i*3)), nil),
+		})
+		if len(allblobies.Objects) != 2 {
+			t.Errorf("Total number of blobs on frame %d should be %d, but got %d", i, 2, len(allblobies.Objects))
+		}
+	}
+	for _, b := range allblobies.Objects {
+		if len(b.GetTrack()) != 5 {
+			t.Errorf("Each blob should have track of length %d, but got %d", 5, len(b.GetTrack()))
+		}
+	}
+}
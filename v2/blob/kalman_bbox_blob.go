@@ -0,0 +1,373 @@
+package blob
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/mat"
+
+	uuid "github.com/satori/go.uuid"
+	"gocv.io/x/gocv"
+)
+
+// KalmanBBoxBlobie Blob implementation based on Kalman filter over the whole bounding box (SORT-style).
+// State of filter is [cx, cy, s, r, vcx, vcy, vs, vr], where (cx, cy) is center of box, s is area of box and r is aspect ratio (width / height).
+// So both position and size of the box are smoothed and predicted.
+// For more ref. see: https://arxiv.org/abs/1602.00763
+type KalmanBBoxBlobie struct {
+	ID                    uuid.UUID
+	CurrentRect           image.Rectangle
+	Center                image.Point
+	Area                  float64
+	Diagonal              float64
+	AspectRatio           float64
+	Track                 []image.Point
+	RectTrack             []image.Rectangle
+	TrackTime             []time.Time
+	maxPointsInTrack      int
+	isExists              bool
+	isStillBeingTracked   bool
+	noMatchTimes          int
+	PredictedNextPosition image.Point
+	PredictedNextRect     image.Rectangle
+
+	trackLifecycle
+
+	classID          int
+	className        string
+	classVotes       classVoter
+	speedOptions     *SpeedOptions
+	customProperties map[string]interface{}
+
+	// Kalman filter wrapping
+	filter    *linearKalmanFilter
+	zMatrix   *mat.Dense
+	dt        float64
+	predicted bool
+	// Variable time step support
+	deriveTimeDelta bool
+	filterTime      time.Time
+	posteriorX      *mat.Dense
+	posteriorP      *mat.Dense
+	baseQ           *mat.Dense
+	// accelerationVariances - Variances of acceleration for process noise of time steps differing from nominal one
+	accelerationVariances []float64
+
+	// For array tracker
+	drawingOptions *DrawOptions
+	crossedLine    bool
+	// lineSide - Last strict side of the line checked by CheckObliqueLineCrossing* methods
+	lineSide int
+}
+
+// NewKalmanBBoxBlobie - Constructor for KalmanBBoxBlobie (default values)
+func NewKalmanBBoxBlobie(rect image.Rectangle, options *BlobOptions) Blobie {
+	center := image.Pt((rect.Min.X*2+rect.Dx())/2, (rect.Min.Y*2+rect.Dy())/2)
+	width := float64(rect.Dx())
+	height := float64(rect.Dy())
+	kalmanBlobie := KalmanBBoxBlobie{
+		CurrentRect:           rect,
+		Center:                center,
+		Area:                  width * height,
+		Diagonal:              math.Sqrt(math.Pow(width, 2) + math.Pow(height, 2)),
+		AspectRatio:           width / height,
+		Track:                 []image.Point{center},
+		RectTrack:             []image.Rectangle{rect},
+		isExists:              true,
+		isStillBeingTracked:   true,
+		noMatchTimes:          0,
+		trackLifecycle:        newTrackLifecycle(),
+		zMatrix:               mat.NewDense(4, 1, nil),
+		PredictedNextPosition: center,
+		PredictedNextRect:     rect,
+		crossedLine:           false,
+		customProperties:      make(map[string]interface{}),
+	}
+	if options != nil {
+		kalmanBlobie.TrackTime = []time.Time{options.Time}
+		kalmanBlobie.maxPointsInTrack = options.MaxPointsInTrack
+		kalmanBlobie.classID = options.ClassID
+		kalmanBlobie.className = options.ClassName
+		kalmanBlobie.classVotes = newClassVoter(options.ClassID, options.ClassName, options.MaxPointsInTrack)
+		kalmanBlobie.speedOptions = options.Speed
+		kalmanBlobie.dt = options.TimeDeltaSeconds
+		kalmanBlobie.deriveTimeDelta = options.DeriveTimeDelta
+	} else {
+		kalmanBlobie.TrackTime = []time.Time{time.Now()}
+		kalmanBlobie.maxPointsInTrack = 10
+		kalmanBlobie.classID = -1
+		kalmanBlobie.className = "No class"
+		kalmanBlobie.classVotes = newClassVoter(-1, "No class", 10)
+		kalmanBlobie.dt = 1.0
+	}
+	centerX, centerY := float64(rect.Min.X+rect.Max.X)/2.0, float64(rect.Min.Y+rect.Max.Y)/2.0
+	kalmanBlobie.filter = newBBoxKalmanFilter(centerX, centerY, kalmanBlobie.Area, kalmanBlobie.AspectRatio, kalmanBlobie.dt)
+	kalmanBlobie.filterTime = kalmanBlobie.TrackTime[0]
+	kalmanBlobie.posteriorX = mat.DenseCopyOf(kalmanBlobie.filter.X)
+	kalmanBlobie.posteriorP = mat.DenseCopyOf(kalmanBlobie.filter.P)
+	kalmanBlobie.baseQ = mat.DenseCopyOf(kalmanBlobie.filter.Q)
+	kalmanBlobie.accelerationVariances = accelerationVariances(kalmanBlobie.baseQ, kalmanBlobie.dt)
+	return &kalmanBlobie
+}
+
+// newBBoxKalmanFilter - Creates linear Kalman filter for constant velocity model with state (cx, cy, s, r, vcx, vcy, vs, vr)
+// Noise values are taken from SORT reference implementation
+func newBBoxKalmanFilter(cx, cy, s, r, dt float64) *linearKalmanFilter {
+	A := mat.NewDense(8, 8, nil)
+	for i := 0; i < 8; i++ {
+		A.Set(i, i, 1)
+	}
+	for i := 0; i < 4; i++ {
+		A.Set(i, i+4, dt)
+	}
+	H := mat.NewDense(4, 8, nil)
+	for i := 0; i < 4; i++ {
+		H.Set(i, i, 1)
+	}
+	return &linearKalmanFilter{
+		A: A,
+		H: H,
+		// High uncertainty for unobservable initial velocities
+		P: mat.NewDense(8, 8, diagonalValues([]float64{10, 10, 10, 10, 1e4, 1e4, 1e4, 1e4})),
+		Q: mat.NewDense(8, 8, diagonalValues([]float64{1, 1, 1, 1, 1e-2, 1e-2, 1e-2, 1e-4})),
+		R: mat.NewDense(4, 4, diagonalValues([]float64{1, 1, 10, 10})),
+		X: mat.NewDense(8, 1, []float64{cx, cy, s, r, 0, 0, 0, 0}),
+	}
+}
+
+// diagonalValues - Returns row-major data of square matrix with provided values on the main diagonal
+func diagonalValues(diagonal []float64) []float64 {
+	n := len(diagonal)
+	data := make([]float64, n*n)
+	for i := range diagonal {
+		data[i*n+i] = diagonal[i]
+	}
+	return data
+}
+
+// stateToRect - Converts filter's state (cx, cy, s, r) to bounding box
+func stateToRect(cx, cy, s, r float64) image.Rectangle {
+	if s <= 0 || r <= 0 {
+		return image.Rect(int(cx), int(cy), int(cx), int(cy))
+	}
+	width := math.Sqrt(s * r)
+	height := s / width
+	return image.Rect(int(math.Round(cx-width/2.0)), int(math.Round(cy-height/2.0)), int(math.Round(cx+width/2.0)), int(math.Round(cy+height/2.0)))
+}
+
+// PredictNextPosition - Predict next position and bounding box of blob via predict step of Kalman filter
+//
+// Each call advances filter state by one time step (dt), so blobs which are not matched on some frames keep moving along their velocity.
+// Argument n is ignored: filter's state already accumulates history of the track
+func (b *KalmanBBoxBlobie) PredictNextPosition(n int) {
+	// Area could not become negative
+	if b.filter.X.At(2, 0)+b.filter.X.At(6, 0)*b.dt <= 0 {
+		b.filter.X.Set(6, 0, 0)
+	}
+	b.filter.predict()
+	b.predicted = true
+	x := b.filter.X
+	b.PredictedNextPosition = image.Pt(int(x.At(0, 0)), int(x.At(1, 0)))
+	b.PredictedNextRect = stateToRect(x.At(0, 0), x.At(1, 0), x.At(2, 0), x.At(3, 0))
+}
+
+// setTimeDelta - Rebuilds transition and process noise matrices of filter for provided time step
+// Nominal process noise is restored for nominal time step, otherwise it is built by white acceleration model (see whiteAccelerationNoise)
+func (b *KalmanBBoxBlobie) setTimeDelta(dt float64) {
+	for i := 0; i < 4; i++ {
+		b.filter.A.Set(i, i+4, dt)
+	}
+	if dt == b.dt {
+		b.filter.Q.Copy(b.baseQ)
+		return
+	}
+	b.filter.Q.Copy(whiteAccelerationNoise(dt, b.accelerationVariances))
+}
+
+// GetPredictedNextRect Returns predicted bounding box of blob [KalmanBBoxBlobie]
+func (b *KalmanBBoxBlobie) GetPredictedNextRect() image.Rectangle {
+	return b.PredictedNextRect
+}
+
+// GetVelocity - Returns velocity of blob's center (pixels per second when dt is measured in seconds) according to current filter's state
+func (b *KalmanBBoxBlobie) GetVelocity() (float64, float64) {
+	return b.filter.X.At(4, 0), b.filter.X.At(5, 0)
+}
+
+// Update - Update info about blob
+func (b *KalmanBBoxBlobie) Update(newb Blobie) error {
+	var newbCast *KalmanBBoxBlobie
+	switch newb.(type) {
+	case *KalmanBBoxBlobie:
+		newbCast = newb.(*KalmanBBoxBlobie)
+		break
+	default:
+		return fmt.Errorf("KalmanBBoxBlobie.Update() method must accept interface of type *KalmanBBoxBlobie")
+	}
+	rect := newbCast.CurrentRect
+	b.zMatrix.Set(0, 0, float64(rect.Min.X+rect.Max.X)/2.0)
+	b.zMatrix.Set(1, 0, float64(rect.Min.Y+rect.Max.Y)/2.0)
+	b.zMatrix.Set(2, 0, newbCast.Area)
+	b.zMatrix.Set(3, 0, newbCast.AspectRatio)
+
+	newTime := newbCast.TrackTime[len(newbCast.TrackTime)-1]
+	if b.deriveTimeDelta {
+		if dt, ok := timeDeltaSeconds(b.filterTime, newTime); ok {
+			// Re-predict from the last corrected state with actual time step instead of nominal steps done by PredictNextPosition()
+			b.filter.X.Copy(b.posteriorX)
+			b.filter.P.Copy(b.posteriorP)
+			b.setTimeDelta(dt)
+			b.filter.predict()
+			b.setTimeDelta(b.dt)
+			b.predicted = true
+		}
+	}
+
+	// Evaluate state: predict step could be done already via PredictNextPosition()
+	if !b.predicted {
+		b.filter.predict()
+	}
+	err := b.filter.update(b.zMatrix)
+	if err != nil {
+		return errors.Wrap(err, "Can't process linear Kalman filter")
+	}
+	b.predicted = false
+	b.filterTime = newTime
+	b.posteriorX.Copy(b.filter.X)
+	b.posteriorP.Copy(b.filter.P)
+
+	x := b.filter.X
+	b.CurrentRect = stateToRect(x.At(0, 0), x.At(1, 0), x.At(2, 0), x.At(3, 0))
+	b.Center = image.Pt(int(x.At(0, 0)), int(x.At(1, 0)))
+	width := float64(b.CurrentRect.Dx())
+	height := float64(b.CurrentRect.Dy())
+	b.Area = width * height
+	b.Diagonal = math.Sqrt(math.Pow(width, 2) + math.Pow(height, 2))
+	if height > 0 {
+		b.AspectRatio = width / height
+	}
+	b.classID, b.className = b.classVotes.vote(b.classID, newbCast.classID, newbCast.className)
+	b.isStillBeingTracked = true
+	b.isExists = true
+	b.noMatchTimes = 0
+	b.registerHit()
+	// Append new point to track
+	b.Track = append(b.Track, b.Center)
+	b.RectTrack = append(b.RectTrack, b.CurrentRect)
+	b.TrackTime = append(b.TrackTime, newbCast.TrackTime[len(newbCast.TrackTime)-1])
+	// Restrict number of points in track (shift to the left)
+	if len(b.Track) > b.maxPointsInTrack {
+		b.Track = b.Track[1:]
+		b.RectTrack = b.RectTrack[1:]
+		b.TrackTime = b.TrackTime[1:]
+	}
+	return nil
+}
+
+func (sb *KalmanBBoxBlobie) GetID() uuid.UUID {
+	return sb.ID
+}
+
+func (sb *KalmanBBoxBlobie) GetCenter() image.Point {
+	return sb.Center
+}
+
+func (sb *KalmanBBoxBlobie) GetCurrentRect() image.Rectangle {
+	return sb.CurrentRect
+}
+
+func (sb *KalmanBBoxBlobie) GetTrack() []image.Point {
+	return sb.Track
+}
+
+func (sb *KalmanBBoxBlobie) GetRectTrack() []image.Rectangle {
+	return sb.RectTrack
+}
+
+func (sb *KalmanBBoxBlobie) GetTimestamps() []time.Time {
+	return sb.TrackTime
+}
+
+func (sb *KalmanBBoxBlobie) GetDiagonal() float64 {
+	return sb.Diagonal
+}
+
+func (sb *KalmanBBoxBlobie) GetPredictedNextPosition() image.Point {
+	return sb.PredictedNextPosition
+}
+
+func (sb *KalmanBBoxBlobie) NoMatchTimes() int {
+	return sb.noMatchTimes
+}
+
+func (sb *KalmanBBoxBlobie) Exists() bool {
+	return sb.isExists
+}
+
+func (sb *KalmanBBoxBlobie) SetID(id uuid.UUID) {
+	sb.ID = id
+}
+
+func (sb *KalmanBBoxBlobie) SetTracking(isStillBeingTracked bool) {
+	sb.isStillBeingTracked = isStillBeingTracked
+}
+
+func (sb *KalmanBBoxBlobie) IncrementNoMatchTimes() {
+	sb.noMatchTimes++
+}
+
+func (sb *KalmanBBoxBlobie) SetExists(isExists bool) {
+	sb.isExists = isExists
+}
+
+// GetClassID Returns class identifier [KalmanBBoxBlobie]
+func (b *KalmanBBoxBlobie) GetClassID() int {
+	return b.classID
+}
+
+// GetClassName Returns class name [KalmanBBoxBlobie]
+func (b *KalmanBBoxBlobie) GetClassName() string {
+	return b.className
+}
+
+func (b *KalmanBBoxBlobie) GetProperty(key string) (interface{}, bool) {
+	v, ok := b.customProperties[key]
+	return v, ok
+}
+
+func (b *KalmanBBoxBlobie) SetProperty(key string, value interface{}) {
+	b.customProperties[key] = value
+}
+
+// SetDraw Sets options for drawing [KalmanBBoxBlobie]
+func (b *KalmanBBoxBlobie) SetDraw(drawOptions *DrawOptions) {
+	b.drawingOptions = drawOptions
+}
+
+// DrawTrack Draws blob's track [KalmanBBoxBlobie]
+func (b *KalmanBBoxBlobie) DrawTrack(mat *gocv.Mat, optionalText ...string) {
+	if b.drawingOptions == nil {
+		b.drawingOptions = NewDrawOptionsDefault()
+	}
+	gocv.Rectangle(mat, b.CurrentRect, b.drawingOptions.BBoxColor.Color, b.drawingOptions.BBoxColor.Thickness)
+	if b.isStillBeingTracked {
+		for i := range b.Track {
+			gocv.Circle(mat, b.Track[i], b.drawingOptions.CentroidColor.Radius, b.drawingOptions.CentroidColor.Color, b.drawingOptions.CentroidColor.Thickness)
+		}
+		shiftTextY := 0
+		for i := 0; i < len(optionalText); i++ {
+			text := optionalText[i]
+			if text != "" {
+				textSize := gocv.GetTextSize(text, b.drawingOptions.TextColor.Font, b.drawingOptions.TextColor.Scale, b.drawingOptions.TextColor.Thickness)
+				anchor := image.Pt(b.CurrentRect.Min.X, b.CurrentRect.Min.Y-shiftTextY-b.drawingOptions.BBoxColor.Thickness) // substract extra margin = Thickness of BBox
+				textRect := image.Rectangle{Min: image.Point{X: anchor.X, Y: anchor.Y - textSize.Y}, Max: image.Point{X: anchor.X + textSize.X, Y: anchor.Y}}
+				gocv.Rectangle(mat, textRect, b.drawingOptions.BBoxColor.Color, b.drawingOptions.BBoxColor.Thickness)
+				gocv.PutText(mat, text, anchor, b.drawingOptions.TextColor.Font, b.drawingOptions.TextColor.Scale, b.drawingOptions.TextColor.Color, b.drawingOptions.TextColor.Thickness)
+				shiftTextY += textSize.Y
+			}
+		}
+	}
+}
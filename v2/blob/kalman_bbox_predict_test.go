@@ -0,0 +1,88 @@
+package blob
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestKalmanBBoxPredict(t *testing.T) {
+	commonOptions := BlobOptions{
+		ClassID:          1,
+		ClassName:        "just_an_object",
+		MaxPointsInTrack: 150,
+		TimeDeltaSeconds: 1.0,
+	}
+	// Object moves with constant velocity (4, 2) per step and grows by 2 pixels in both dimensions per step. Size of detected box jitters
+	jitter := []int{0, 3, -3, 2, -2, 3, -3, 1, -1, 0}
+	makeRect := func(i int) image.Rectangle {
+		center := image.Pt(100+i*4, 100+i*2)
+		halfWidth := 40 + i + jitter[i%len(jitter)]
+		halfHeight := 20 + i
+		return image.Rect(center.X-halfWidth, center.Y-halfHeight, center.X+halfWidth, center.Y+halfHeight)
+	}
+	var b Blobie
+	maxWidthError := 0.0
+	for i := 0; i < 40; i++ {
+		blob := NewKalmanBBoxBlobie(makeRect(i), &commonOptions)
+		if b == nil {
+			b = blob
+		}
+		b.PredictNextPosition(5)
+		if err := b.Update(blob); err != nil {
+			t.Error(err)
+			return
+		}
+		if i > 10 {
+			// Smoothed width should be closer to the true one than the jittered detection
+			trueWidth := float64(2 * (40 + i))
+			maxWidthError = math.Max(maxWidthError, math.Abs(float64(b.GetCurrentRect().Dx())-trueWidth))
+		}
+	}
+	if maxWidthError >= 6 {
+		t.Errorf("Smoothed width error should be less than %d, but got %f", 6, maxWidthError)
+	}
+
+	forCheck := b.(*KalmanBBoxBlobie)
+	vx, vy := forCheck.GetVelocity()
+	if math.Abs(vx-4) > 0.5 || math.Abs(vy-2) > 0.5 {
+		t.Errorf("Velocity should be close to (%d, %d), but got (%f, %f)", 4, 2, vx, vy)
+	}
+	b.PredictNextPosition(5)
+	predicted := forCheck.GetPredictedNextRect()
+	correct := makeRect(40)
+	if iou := IoU(predicted, correct); iou < 0.85 {
+		t.Errorf("IoU between predicted box %v and correct box %v should be at least %f, but got %f", predicted, correct, 0.85, iou)
+	}
+}
+
+func TestKalmanBBoxArrayTracker(t *testing.T) {
+	allblobies := NewBlobiesDefaults()
+	rectHalfHeight := 30
+	rectHalfWidth := 75
+	correctOverallBlobies := 3
+	commonOptions := BlobOptions{
+		ClassID:          1,
+		ClassName:        "just_an_object",
+		MaxPointsInTrack: 150,
+		TimeDeltaSeconds: 1.0,
+	}
+	for i := range objectOne {
+		centerOne := objectOne[i]
+		centerTwo := objectTwo[i]
+		centerThree := objectThree[i]
+
+		rectOne := image.Rect(centerOne[0]-rectHalfWidth, centerOne[1]-rectHalfHeight, centerOne[0]+rectHalfWidth, centerOne[1]+rectHalfHeight)
+		rectTwo := image.Rect(centerTwo[0]-rectHalfWidth, centerTwo[1]-rectHalfHeight, centerTwo[0]+rectHalfWidth, centerTwo[1]+rectHalfHeight)
+		rectThree := image.Rect(centerThree[0]-rectHalfWidth, centerThree[1]-rectHalfHeight, centerThree[0]+rectHalfWidth, centerThree[1]+rectHalfHeight)
+
+		blobOne := NewKalmanBBoxBlobie(rectOne, &commonOptions)
+		blobTwo := NewKalmanBBoxBlobie(rectTwo, &commonOptions)
+		blobThree := NewKalmanBBoxBlobie(rectThree, &commonOptions)
+		allblobies.MatchToExisting([]Blobie{blobOne, blobTwo, blobThree})
+
+		if correctOverallBlobies != len(allblobies.Objects) {
+			t.Errorf("[KalmanBBox] Total number of blobs on frame %d should be %d, bot got %d", i, correctOverallBlobies, len(allblobies.Objects))
+		}
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"math"
 	"time"
 
-	kf "github.com/LdDl/kalman-filter"
 	"github.com/pkg/errors"
 	"gonum.org/v1/gonum/mat"
 
@@ -24,6 +23,7 @@ type KalmanBlobie struct {
 	Diagonal              float64
 	AspectRatio           float64
 	Track                 []image.Point
+	RectTrack             []image.Rectangle
 	TrackTime             []time.Time
 	maxPointsInTrack      int
 	isExists              bool
@@ -31,19 +31,33 @@ type KalmanBlobie struct {
 	noMatchTimes          int
 	PredictedNextPosition image.Point
 
+	trackLifecycle
+
 	classID          int
 	className        string
+	classVotes       classVoter
+	speedOptions     *SpeedOptions
 	customProperties map[string]interface{}
 
 	// Kalman filter wrapping
-	pointTracker *kf.PointTracker
-	yMatrix      *mat.Dense
-	uMatrix      *mat.Dense
-	dt           float64
+	filter    *linearKalmanFilter
+	zMatrix   *mat.Dense
+	dt        float64
+	predicted bool
+	// Variable time step support
+	deriveTimeDelta bool
+	filterTime      time.Time
+	posteriorX      *mat.Dense
+	posteriorP      *mat.Dense
+	baseQ           *mat.Dense
+	// accelerationVariances - Variances of acceleration for process noise of time steps differing from nominal one
+	accelerationVariances []float64
 
 	// For array tracker
 	drawingOptions *DrawOptions
 	crossedLine    bool
+	// lineSide - Last strict side of the line checked by CheckObliqueLineCrossing* methods
+	lineSide int
 }
 
 // NewKalmanBlobie - Constructor for KalmanBlobie (default values)
@@ -59,53 +73,106 @@ func NewKalmanBlobie(rect image.Rectangle, options *BlobOptions) Blobie {
 		Diagonal:            math.Sqrt(math.Pow(width, 2) + math.Pow(height, 2)),
 		AspectRatio:         width / height,
 		Track:               []image.Point{center},
+		RectTrack:           []image.Rectangle{rect},
 		isExists:            true,
 		isStillBeingTracked: true,
 		noMatchTimes:        0,
-		pointTracker:        kf.NewPointTracker(),
-		yMatrix:             mat.NewDense(2, 1, []float64{centerX, centerY}),
-		uMatrix:             mat.NewDense(4, 1, []float64{0.0, 0.0, 0.0, 0.0}),
+		trackLifecycle:      newTrackLifecycle(),
+		zMatrix:             mat.NewDense(2, 1, []float64{centerX, centerY}),
 		crossedLine:         false,
 		customProperties:    make(map[string]interface{}),
 	}
-	kalmanBlobie.pointTracker.SetStateValue(centerX, centerY, 0, 0)
 	if options != nil {
 		kalmanBlobie.TrackTime = []time.Time{options.Time}
 		kalmanBlobie.maxPointsInTrack = options.MaxPointsInTrack
 		kalmanBlobie.classID = options.ClassID
 		kalmanBlobie.className = options.ClassName
+		kalmanBlobie.classVotes = newClassVoter(options.ClassID, options.ClassName, options.MaxPointsInTrack)
+		kalmanBlobie.speedOptions = options.Speed
 		kalmanBlobie.dt = options.TimeDeltaSeconds
-		kalmanBlobie.pointTracker.SetTime(options.TimeDeltaSeconds)
+		kalmanBlobie.deriveTimeDelta = options.DeriveTimeDelta
 	} else {
 		kalmanBlobie.TrackTime = []time.Time{time.Now()}
 		kalmanBlobie.maxPointsInTrack = 10
 		kalmanBlobie.classID = -1
 		kalmanBlobie.className = "No class"
+		kalmanBlobie.classVotes = newClassVoter(-1, "No class", 10)
 		kalmanBlobie.dt = 1.0
-		kalmanBlobie.pointTracker.SetTime(1.0)
 	}
+	kalmanBlobie.filter = newPointKalmanFilter(centerX, centerY, kalmanBlobie.dt)
+	kalmanBlobie.filterTime = kalmanBlobie.TrackTime[0]
+	kalmanBlobie.posteriorX = mat.DenseCopyOf(kalmanBlobie.filter.X)
+	kalmanBlobie.posteriorP = mat.DenseCopyOf(kalmanBlobie.filter.P)
+	kalmanBlobie.baseQ = mat.DenseCopyOf(kalmanBlobie.filter.Q)
+	kalmanBlobie.accelerationVariances = accelerationVariances(kalmanBlobie.baseQ, kalmanBlobie.dt)
 	return &kalmanBlobie
 }
 
-// PredictNextPosition - Predict next N coordinates
-func (b *KalmanBlobie) PredictNextPosition(n int) {
-	account := min(n, len(b.Track))
-	current := len(b.Track) - 1
-	prev := current - 1
-	deltaX, deltaY, sum := 0, 0, 0
-	for i := 1; i < int(account); i++ {
-		deltaX += (b.Track[current].X - b.Track[prev].X) * (account - i)
-		deltaY += (b.Track[current].Y - b.Track[prev].Y) * (account - i)
-		sum += i
-		current = prev
-		prev = current - 1
+// pointProcessNoise - Process noise of point Kalman filter for nominal time step
+const pointProcessNoise = 1e-5
+
+// newPointKalmanFilter - Creates linear Kalman filter for constant velocity model with state (x, y, vx, vy)
+func newPointKalmanFilter(x, y, dt float64) *linearKalmanFilter {
+	return &linearKalmanFilter{
+		A: mat.NewDense(4, 4, []float64{
+			1, 0, dt, 0,
+			0, 1, 0, dt,
+			0, 0, 1, 0,
+			0, 0, 0, 1,
+		}),
+		H: mat.NewDense(2, 4, []float64{
+			1, 0, 0, 0,
+			0, 1, 0, 0,
+		}),
+		P: mat.NewDense(4, 4, []float64{
+			1, 0, 0, 0,
+			0, 1, 0, 0,
+			0, 0, 1, 0,
+			0, 0, 0, 1,
+		}),
+		Q: mat.NewDense(4, 4, diagonalValues([]float64{pointProcessNoise, pointProcessNoise, pointProcessNoise, pointProcessNoise})),
+		R: mat.NewDense(2, 2, []float64{
+			1e-1, 0,
+			0, 1e-1,
+		}),
+		X: mat.NewDense(4, 1, []float64{x, y, 0, 0}),
 	}
-	if sum > 0 {
-		deltaX /= sum
-		deltaY /= sum
+}
+
+// PredictNextPosition - Predict next position of blob via predict step of Kalman filter
+//
+// Each call advances filter state by one time step (dt), so blobs which are not matched on some frames keep moving along their velocity.
+// Argument n is ignored: filter's state already accumulates history of the track
+func (b *KalmanBlobie) PredictNextPosition(n int) {
+	b.filter.predict()
+	b.predicted = true
+	b.PredictedNextPosition = image.Pt(int(b.filter.X.At(0, 0)), int(b.filter.X.At(1, 0)))
+}
+
+// PredictPositionAhead - Returns position of blob expected after given number of time steps (dt) according to current filter's state
+// It does not change filter's state
+func (b *KalmanBlobie) PredictPositionAhead(steps int) image.Point {
+	x, y := b.filter.X.At(0, 0), b.filter.X.At(1, 0)
+	vx, vy := b.GetVelocity()
+	horizon := float64(steps) * b.dt
+	return image.Pt(int(x+vx*horizon), int(y+vy*horizon))
+}
+
+// setTimeDelta - Rebuilds transition and process noise matrices of filter for provided time step
+// Nominal process noise is restored for nominal time step, otherwise it is built by white acceleration model (see whiteAccelerationNoise)
+func (b *KalmanBlobie) setTimeDelta(dt float64) {
+	b.filter.A.Set(0, 2, dt)
+	b.filter.A.Set(1, 3, dt)
+	if dt == b.dt {
+		b.filter.Q.Copy(b.baseQ)
+		return
 	}
-	b.PredictedNextPosition.X = b.Track[len(b.Track)-1].X + deltaX
-	b.PredictedNextPosition.Y = b.Track[len(b.Track)-1].Y + deltaY
+	b.filter.Q.Copy(whiteAccelerationNoise(dt, b.accelerationVariances))
+}
+
+// GetVelocity - Returns velocity of blob (pixels per second when dt is measured in seconds) according to current filter's state
+func (b *KalmanBlobie) GetVelocity() (float64, float64) {
+	return b.filter.X.At(2, 0), b.filter.X.At(3, 0)
 }
 
 // Update - Update info about blob
@@ -120,22 +187,36 @@ func (b *KalmanBlobie) Update(newb Blobie) error {
 	}
 	newCenterX, newCenterY := float64(newbCast.Center.X), float64(newbCast.Center.Y)
 
-	// Reset y
-	b.yMatrix.Set(0, 0, newCenterX)
-	b.yMatrix.Set(1, 0, newCenterY)
-
-	// Reset u
-	b.uMatrix.Set(0, 0, 0.0)
-	b.uMatrix.Set(1, 0, 0.0)
-	b.uMatrix.Set(2, 0, 0.0)
-	b.uMatrix.Set(3, 0, 0.0)
+	// Reset observation
+	b.zMatrix.Set(0, 0, newCenterX)
+	b.zMatrix.Set(1, 0, newCenterY)
+
+	newTime := newbCast.TrackTime[len(newbCast.TrackTime)-1]
+	if b.deriveTimeDelta {
+		if dt, ok := timeDeltaSeconds(b.filterTime, newTime); ok {
+			// Re-predict from the last corrected state with actual time step instead of nominal steps done by PredictNextPosition()
+			b.filter.X.Copy(b.posteriorX)
+			b.filter.P.Copy(b.posteriorP)
+			b.setTimeDelta(dt)
+			b.filter.predict()
+			b.setTimeDelta(b.dt)
+			b.predicted = true
+		}
+	}
 
-	// Evaluate state
-	state, err := b.pointTracker.Process(b.uMatrix, b.yMatrix)
+	// Evaluate state: predict step could be done already via PredictNextPosition()
+	if !b.predicted {
+		b.filter.predict()
+	}
+	err := b.filter.update(b.zMatrix)
 	if err != nil {
 		return errors.Wrap(err, "Can't process linear Kalman filter")
 	}
-	kalmanX, kalmanY := int(state.At(0, 0)), int(state.At(1, 0))
+	b.predicted = false
+	b.filterTime = newTime
+	b.posteriorX.Copy(b.filter.X)
+	b.posteriorP.Copy(b.filter.P)
+	kalmanX, kalmanY := int(b.filter.X.At(0, 0)), int(b.filter.X.At(1, 0))
 	b.CurrentRect = newbCast.CurrentRect
 	b.Center = image.Point{kalmanX, kalmanY}
 	diffX, diffY := kalmanX-newbCast.Center.X, kalmanY-newbCast.Center.Y
@@ -143,14 +224,20 @@ func (b *KalmanBlobie) Update(newb Blobie) error {
 	b.Area = newbCast.Area
 	b.Diagonal = newbCast.Diagonal
 	b.AspectRatio = newbCast.AspectRatio
+	b.classID, b.className = b.classVotes.vote(b.classID, newbCast.classID, newbCast.className)
 	b.isStillBeingTracked = true
 	b.isExists = true
+	b.noMatchTimes = 0
+	b.registerHit()
 	// Append new point to track
 	b.Track = append(b.Track, b.Center)
+	b.RectTrack = append(b.RectTrack, b.CurrentRect)
 	b.TrackTime = append(b.TrackTime, newbCast.TrackTime[len(newbCast.TrackTime)-1])
 	// Restrict number of points in track (shift to the left)
 	if len(b.Track) > b.maxPointsInTrack {
 		b.Track = b.Track[1:]
+		b.RectTrack = b.RectTrack[1:]
+		b.TrackTime = b.TrackTime[1:]
 	}
 	return nil
 }
@@ -171,6 +258,10 @@ func (sb *KalmanBlobie) GetTrack() []image.Point {
 	return sb.Track
 }
 
+func (sb *KalmanBlobie) GetRectTrack() []image.Rectangle {
+	return sb.RectTrack
+}
+
 func (sb *KalmanBlobie) GetTimestamps() []time.Time {
 	return sb.TrackTime
 }
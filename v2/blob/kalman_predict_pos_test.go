@@ -2,7 +2,11 @@ package blob
 
 import (
 	"image"
+	"math"
 	"testing"
+	"time"
+
+	"gonum.org/v1/gonum/mat"
 )
 
 func TestKalmanPredictPos(t *testing.T) {
@@ -29,14 +33,15 @@ func TestKalmanPredictPos(t *testing.T) {
 			[]int{13, 13},
 			[]int{17, 17},
 		}
+		// Predictions are made by predict step of Kalman filter (before corresponding update)
 		correctPredictions = [][]int{
-			[]int{0, 0},
 			[]int{0, 0},
 			[]int{0, 0},
 			[]int{1, 1},
+			[]int{2, 2},
 			[]int{4, 4},
 			[]int{6, 6},
-			[]int{8, 8},
+			[]int{9, 9},
 			[]int{12, 12},
 			[]int{15, 15},
 		}
@@ -85,3 +90,129 @@ func TestKalmanPredictPos(t *testing.T) {
 		}
 	}
 }
+
+func TestKalmanPredictPosCoasting(t *testing.T) {
+	rectHalfHeight := 30
+	rectHalfWidth := 75
+	commonOptions := BlobOptions{
+		ClassID:          1,
+		ClassName:        "just_an_object",
+		MaxPointsInTrack: 150,
+		TimeDeltaSeconds: 1.0,
+	}
+	var b Blobie
+	// Object moves with constant velocity (10, 5) per step
+	for i := 0; i < 30; i++ {
+		center := image.Pt(i*10, i*5)
+		rect := image.Rect(center.X-rectHalfWidth, center.Y-rectHalfHeight, center.X+rectHalfWidth, center.Y+rectHalfHeight)
+		blob := NewKalmanBlobie(rect, &commonOptions)
+		if b == nil {
+			b = blob
+		}
+		b.PredictNextPosition(5)
+		b.Update(blob)
+	}
+	forCheck := b.(*KalmanBlobie)
+	vx, vy := forCheck.GetVelocity()
+	if math.Abs(vx-10) > 1 || math.Abs(vy-5) > 1 {
+		t.Errorf("Velocity should be close to (%d, %d), but got (%f, %f)", 10, 5, vx, vy)
+	}
+	ahead := forCheck.PredictPositionAhead(3)
+	if distanceBetweenPoints(ahead, image.Pt(320, 160)) > 5 {
+		t.Errorf("Position after %d steps should be close to %v, but got %v", 3, image.Pt(320, 160), ahead)
+	}
+	// No detections for few frames: predicted position should keep moving
+	previous := forCheck.Center
+	for i := 0; i < 3; i++ {
+		b.PredictNextPosition(5)
+		predicted := b.GetPredictedNextPosition()
+		if predicted.X <= previous.X || predicted.Y <= previous.Y {
+			t.Errorf("Predicted position on coasting step %d should move forward from %v, but got %v", i, previous, predicted)
+		}
+		previous = predicted
+	}
+}
+
+func TestKalmanVariableTimeDelta(t *testing.T) {
+	rectHalfHeight := 30
+	rectHalfWidth := 75
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Object moves with constant velocity 100 pixels per second, but frames are dropped and timestamps are jittery
+	timestamps := []float64{0, 0.04, 0.08, 0.2, 0.24, 0.29, 0.32, 0.4, 0.52, 0.56, 0.6, 0.65, 0.68, 0.8, 0.84, 0.88, 1.0, 1.04, 1.12, 1.16}
+	for _, newBlob := range []func(image.Rectangle, *BlobOptions) Blobie{NewKalmanBlobie, NewKalmanBBoxBlobie} {
+		var b Blobie
+		for i, seconds := range timestamps {
+			center := image.Pt(int(100*seconds), 50)
+			rect := image.Rect(center.X-rectHalfWidth, center.Y-rectHalfHeight, center.X+rectHalfWidth, center.Y+rectHalfHeight)
+			options := BlobOptions{
+				ClassID:          1,
+				ClassName:        "just_an_object",
+				MaxPointsInTrack: 150,
+				Time:             startTime.Add(time.Duration(seconds * float64(time.Second))),
+				TimeDeltaSeconds: 0.04,
+				DeriveTimeDelta:  true,
+			}
+			blob := newBlob(rect, &options)
+			if b == nil {
+				b = blob
+				continue
+			}
+			// Predictions with nominal time step should not break estimation
+			if i%3 == 0 {
+				b.PredictNextPosition(5)
+			}
+			if err := b.Update(blob); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+		var vx float64
+		switch forCheck := b.(type) {
+		case *KalmanBlobie:
+			vx, _ = forCheck.GetVelocity()
+		case *KalmanBBoxBlobie:
+			vx, _ = forCheck.GetVelocity()
+		}
+		if math.Abs(vx-100) > 15 {
+			t.Errorf("[%T] Velocity should be close to %d pixels per second, but got %f", b, 100, vx)
+		}
+	}
+}
+
+func TestKalmanProcessNoiseTimeStep(t *testing.T) {
+	// Process noise alone is compared: one step of 2*dt against two steps of dt starting from the same exact state.
+	// Both steps differ from nominal one, otherwise nominal process noise is used
+	const dt, nominal = 0.04, 0.1
+	rect := image.Rect(0, 0, 40, 20)
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10, TimeDeltaSeconds: nominal}
+	type filterAccess struct {
+		filter       *linearKalmanFilter
+		setTimeDelta func(dt float64)
+	}
+	kalman := NewKalmanBlobie(rect, &options).(*KalmanBlobie)
+	bbox := NewKalmanBBoxBlobie(rect, &options).(*KalmanBBoxBlobie)
+	for _, access := range []filterAccess{{kalman.filter, kalman.setTimeDelta}, {bbox.filter, bbox.setTimeDelta}} {
+		n, _ := access.filter.P.Dims()
+		positions := n / 2
+		covariance := func(steps int, step float64) *mat.Dense {
+			access.filter.P.Zero()
+			access.setTimeDelta(step)
+			for i := 0; i < steps; i++ {
+				access.filter.predict()
+			}
+			access.setTimeDelta(nominal)
+			return mat.DenseCopyOf(access.filter.P)
+		}
+		single, double := covariance(1, 2*dt), covariance(2, dt)
+		for i := 0; i < positions; i++ {
+			// Piecewise white acceleration model: one long step accumulates the same order of uncertainty as two short ones
+			// (positions 4 vs 2.5, cross terms and velocities 4 vs 2 in units of dt^4, dt^3 and dt^2), linear scaling of diagonal Q gives no cross terms at all
+			for _, cell := range [][2]int{{i, i}, {i, i + positions}, {i + positions, i + positions}} {
+				one, two := single.At(cell[0], cell[1]), double.At(cell[0], cell[1])
+				if two <= 0 || one/two < 1-1e-3 || one/two > 2+1e-3 {
+					t.Errorf("[%d x %d] P%v after one step of 2*dt (%g) should be 1-2 times larger than after two steps of dt (%g)", n, n, cell, one, two)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package blob
+
+// TrackState - State of blob's track in terms of its lifecycle
+type TrackState int
+
+const (
+	// TrackTentative - Track has been just registered and has not been confirmed by enough detections yet
+	TrackTentative = TrackState(iota)
+	// TrackConfirmed - Track has been confirmed by enough detections and has been matched on the last frame
+	TrackConfirmed
+	// TrackLost - Track has been confirmed before, but has not been matched on the last frame
+	TrackLost
+	// TrackDeleted - Track has been deregistered
+	TrackDeleted
+)
+
+// String - Returns text representation of TrackState
+func (state TrackState) String() string {
+	switch state {
+	case TrackTentative:
+		return "tentative"
+	case TrackConfirmed:
+		return "confirmed"
+	case TrackLost:
+		return "lost"
+	case TrackDeleted:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+// trackLifecycle - Lifecycle information of blob's track. It is embedded into Blobie implementations
+type trackLifecycle struct {
+	state TrackState
+	hits  int
+	age   int
+}
+
+// newTrackLifecycle - Constructor for trackLifecycle. Blob is born with single hit (detection which produced it)
+func newTrackLifecycle() trackLifecycle {
+	return trackLifecycle{
+		state: TrackTentative,
+		hits:  1,
+		age:   0,
+	}
+}
+
+// GetState Returns current state of track
+func (lc *trackLifecycle) GetState() TrackState {
+	return lc.state
+}
+
+// SetState Sets current state of track
+func (lc *trackLifecycle) SetState(state TrackState) {
+	lc.state = state
+}
+
+// Hits Returns number of detections which have been matched to track (including the very first one)
+func (lc *trackLifecycle) Hits() int {
+	return lc.hits
+}
+
+// Age Returns number of frames passed since track has been registered
+func (lc *trackLifecycle) Age() int {
+	return lc.age
+}
+
+// IncrementAge Increments number of frames passed since track has been registered
+func (lc *trackLifecycle) IncrementAge() {
+	lc.age++
+}
+
+// registerHit - Increments number of matched detections
+func (lc *trackLifecycle) registerHit() {
+	lc.hits++
+}
@@ -0,0 +1,66 @@
+package blob
+
+import (
+	"image"
+	"testing"
+)
+
+func TestTrackLifecycle(t *testing.T) {
+	allblobies, err := NewBlobies(
+		WithMaxNoMatch(3),
+		WithConfirmation(3, 4),
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	rect := image.Rect(100, 100, 140, 140)
+	falsePositive := image.Rect(400, 400, 420, 420)
+
+	// Frame #0: both objects are tentative
+	allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(rect, nil), NewSimpleBlobie(falsePositive, nil)})
+	if n := len(allblobies.ObjectsInState(TrackTentative)); n != 2 {
+		t.Errorf("Number of tentative blobs on frame %d should be %d, but got %d", 0, 2, n)
+	}
+	// Frame #1 and #2: real object gets confirmed, false positive is still tentative
+	for i := 1; i <= 2; i++ {
+		allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(rect.Add(image.Pt(i*2, 0)), nil)})
+	}
+	if n := len(allblobies.ObjectsInState(TrackConfirmed)); n != 1 {
+		t.Errorf("Number of confirmed blobs on frame %d should be %d, but got %d", 2, 1, n)
+	}
+	if n := len(allblobies.ObjectsInState(TrackTentative)); n != 1 {
+		t.Errorf("Number of tentative blobs on frame %d should be %d, but got %d", 2, 1, n)
+	}
+	// Frame #3: real object is lost, false positive is deleted since it has not been confirmed within window
+	allblobies.MatchToExisting([]Blobie{})
+	if len(allblobies.Objects) != 1 {
+		t.Errorf("Total number of blobs on frame %d should be %d, but got %d", 3, 1, len(allblobies.Objects))
+	}
+	var tracked Blobie
+	for _, b := range allblobies.ObjectsInState(TrackLost) {
+		tracked = b
+	}
+	if tracked == nil {
+		t.Errorf("Blob should be lost on frame %d", 3)
+		return
+	}
+	// Frame #4: real object is confirmed again
+	allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(rect.Add(image.Pt(8, 0)), nil)})
+	if tracked.GetState() != TrackConfirmed {
+		t.Errorf("Blob state on frame %d should be %s, but got %s", 4, TrackConfirmed, tracked.GetState())
+	}
+	if tracked.Hits() != 4 || tracked.Age() != 4 {
+		t.Errorf("Blob should have %d hits and age %d, but got %d and %d", 4, 4, tracked.Hits(), tracked.Age())
+	}
+	// Frames #5-#7: real object is deleted after maxNoMatch frames in a row without detections
+	for i := 0; i < 3; i++ {
+		allblobies.MatchToExisting([]Blobie{})
+	}
+	if len(allblobies.Objects) != 0 {
+		t.Errorf("Total number of blobs should be %d, but got %d", 0, len(allblobies.Objects))
+	}
+	if tracked.GetState() != TrackDeleted {
+		t.Errorf("Blob state should be %s, but got %s", TrackDeleted, tracked.GetState())
+	}
+}
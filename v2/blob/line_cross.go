@@ -1,5 +1,9 @@
 package blob
 
+import (
+	"image"
+)
+
 type PointsOrientation int
 
 const (
@@ -89,21 +93,22 @@ func isIntersects(firstPx, firstPy, firstQx, firstQy, secondPx, secondPy, second
 	return false
 }
 
-// IsCrossedTheLine - Check if blob crossed the HORIZONTAL line
-func (b *SimpleBlobie) IsCrossedTheLine(vertical, leftX, rightX int, direction bool) bool {
-	trackLen := len(b.Track)
-	if b.isStillBeingTracked == true && trackLen >= 2 && b.crossedLine == false {
+// isTrackCrossedTheLine - Check if last segment of track crossed the HORIZONTAL line with shift along the Y-axis
+// crossedLine is set to true when crossing happens and no more crossings are reported after that
+func isTrackCrossedTheLine(track []image.Point, isStillBeingTracked bool, crossedLine *bool, vertical, leftX, rightX int, direction bool, shift int) bool {
+	trackLen := len(track)
+	if isStillBeingTracked == true && trackLen >= 2 && *crossedLine == false {
 		prevFrame := trackLen - 2
 		currFrame := trackLen - 1
-		if b.Track[currFrame].X >= leftX && b.Track[currFrame].X <= rightX {
+		if track[currFrame].X >= leftX && track[currFrame].X <= rightX {
 			if direction {
-				if b.Track[prevFrame].Y <= vertical && b.Track[currFrame].Y > vertical { // TO us
-					b.crossedLine = true
+				if (track[prevFrame].Y+shift) <= vertical && (track[currFrame].Y+shift) > vertical { // TO us
+					*crossedLine = true
 					return true
 				}
 			} else {
-				if b.Track[prevFrame].Y > vertical && b.Track[currFrame].Y <= vertical { // FROM us
-					b.crossedLine = true
+				if (track[prevFrame].Y+shift) > vertical && (track[currFrame].Y+shift) <= vertical { // FROM us
+					*crossedLine = true
 					return true
 				}
 			}
@@ -112,22 +117,24 @@ func (b *SimpleBlobie) IsCrossedTheLine(vertical, leftX, rightX int, direction b
 	return false
 }
 
-// IsCrossedTheLineWithShift - Check if blob crossed the HORIZONTAL line with shift along the Y-axis
-// Purpose of this for "predicative" cropping when detection line very close to bottom of image
-func (b *SimpleBlobie) IsCrossedTheLineWithShift(vertical, leftX, rightX int, direction bool, shift int) bool {
-	trackLen := len(b.Track)
-	if b.isStillBeingTracked == true && trackLen >= 2 && b.crossedLine == false {
+// isTrackCrossedTheObliqueLine - Check if last segment of track crossed the OBLIQUE line with shift along the Y-axis
+// crossedLine is set to true when crossing happens and no more crossings are reported after that
+func isTrackCrossedTheObliqueLine(track []image.Point, isStillBeingTracked bool, crossedLine *bool, leftX, leftY, rightX, rightY int, direction bool, shift int) bool {
+	trackLen := len(track)
+	if isStillBeingTracked == true && trackLen >= 2 && *crossedLine == false {
 		prevFrame := trackLen - 2
 		currFrame := trackLen - 1
-		if b.Track[currFrame].X >= leftX && b.Track[currFrame].X <= rightX {
+		// First segment is: P1 = (track[prevFrame].X, track[prevFrame].Y + shift), Q1 = (track[currFrame].X, track[currFrame].Y + shift)
+		// Second segment is: P2 = (leftX, leftY), Q2 = (rightX, rightY)
+		if isIntersects(track[prevFrame].X, track[prevFrame].Y+shift, track[currFrame].X, track[currFrame].Y+shift, leftX, leftY, rightX, rightY) {
 			if direction {
-				if (b.Track[prevFrame].Y+shift) <= vertical && (b.Track[currFrame].Y+shift) > vertical { // TO us
-					b.crossedLine = true
+				if track[currFrame].Y > track[prevFrame].Y { // TO us
+					*crossedLine = true
 					return true
 				}
 			} else {
-				if (b.Track[prevFrame].Y+shift) > vertical && (b.Track[currFrame].Y+shift) <= vertical { // FROM us
-					b.crossedLine = true
+				if track[currFrame].Y <= track[prevFrame].Y { // FROM us
+					*crossedLine = true
 					return true
 				}
 			}
@@ -136,156 +143,98 @@ func (b *SimpleBlobie) IsCrossedTheLineWithShift(vertical, leftX, rightX int, di
 	return false
 }
 
+// IsCrossedTheLine - Check if blob crossed the HORIZONTAL line
+// Blob's track of centroids is tested: use CheckObliqueLineCrossingWithAnchor for other points of bounding box
+func (b *SimpleBlobie) IsCrossedTheLine(vertical, leftX, rightX int, direction bool) bool {
+	return isTrackCrossedTheLine(b.Track, b.isStillBeingTracked, &b.crossedLine, vertical, leftX, rightX, direction, 0)
+}
+
+// IsCrossedTheLineWithShift - Check if blob crossed the HORIZONTAL line with shift along the Y-axis
+// Purpose of this for "predicative" cropping when detection line very close to bottom of image
+// Blob's track of centroids is tested: use CheckObliqueLineCrossingWithAnchor for other points of bounding box
+func (b *SimpleBlobie) IsCrossedTheLineWithShift(vertical, leftX, rightX int, direction bool, shift int) bool {
+	return isTrackCrossedTheLine(b.Track, b.isStillBeingTracked, &b.crossedLine, vertical, leftX, rightX, direction, shift)
+}
+
 // IsCrossedTheObliqueLine - Check if blob crossed the OBLIQUE line
 // This should be used when lineStart.Y != lineEnd.Y
+// Blob's track of centroids is tested
+//
+// Deprecated: direction is decided by comparing Y-coordinates only, which is wrong for near-vertical lines. Use CheckObliqueLineCrossing instead
 func (b *SimpleBlobie) IsCrossedTheObliqueLine(leftX, leftY, rightX, rightY int, direction bool) bool {
-	trackLen := len(b.Track)
-	if b.isStillBeingTracked == true && trackLen >= 2 && b.crossedLine == false {
-		prevFrame := trackLen - 2
-		currFrame := trackLen - 1
-		// First segment is: P1 = (b.Track[prevFrame].X, b.Track[prevFrame].Y), Q1 = (b.Track[currFrame].X, b.Track[currFrame].Y)
-		// Second segment is: P2 = (leftX, leftY), Q2 = (rightX, rightY)
-		if isIntersects(b.Track[prevFrame].X, b.Track[prevFrame].Y, b.Track[currFrame].X, b.Track[currFrame].Y, leftX, leftY, rightX, rightY) {
-			if direction {
-				if b.Track[currFrame].Y > b.Track[prevFrame].Y { // TO us
-					b.crossedLine = true
-					return true
-				}
-			} else {
-				if b.Track[currFrame].Y <= b.Track[prevFrame].Y { // FROM us
-					b.crossedLine = true
-					return true
-				}
-			}
-		}
-	}
-	return false
+	return isTrackCrossedTheObliqueLine(b.Track, b.isStillBeingTracked, &b.crossedLine, leftX, leftY, rightX, rightY, direction, 0)
 }
 
-// IsCrossedTheObliqueLine - Check if blob crossed the OBLIQUE line with shift along the Y-axis
+// IsCrossedTheObliqueLineWithShift - Check if blob crossed the OBLIQUE line with shift along the Y-axis
 // This should be used when lineStart.Y != lineEnd.Y
 // Purpose of shifting: for "predicative" cropping when detection line very close to bottom of image
+// Blob's track of centroids is tested
+//
+// Deprecated: direction is decided by comparing Y-coordinates only, which is wrong for near-vertical lines. Use CheckObliqueLineCrossingWithShift instead
 func (b *SimpleBlobie) IsCrossedTheObliqueLineWithShift(leftX, leftY, rightX, rightY int, direction bool, shift int) bool {
-	trackLen := len(b.Track)
-	if b.isStillBeingTracked == true && trackLen >= 2 && b.crossedLine == false {
-		prevFrame := trackLen - 2
-		currFrame := trackLen - 1
-		// First segment is: P1 = (b.Track[prevFrame].X, b.Track[prevFrame].Y + shift), Q1 = (b.Track[currFrame].X, b.Track[currFrame].Y + shift)
-		// Second segment is: P2 = (leftX, leftY), Q2 = (rightX, rightY)
-		if isIntersects(b.Track[prevFrame].X, b.Track[prevFrame].Y+shift, b.Track[currFrame].X, b.Track[currFrame].Y+shift, leftX, leftY, rightX, rightY) {
-			if direction {
-				if b.Track[currFrame].Y > b.Track[prevFrame].Y { // TO us
-					b.crossedLine = true
-					return true
-				}
-			} else {
-				if b.Track[currFrame].Y <= b.Track[prevFrame].Y { // FROM us
-					b.crossedLine = true
-					return true
-				}
-			}
-		}
-	}
-	return false
+	return isTrackCrossedTheObliqueLine(b.Track, b.isStillBeingTracked, &b.crossedLine, leftX, leftY, rightX, rightY, direction, shift)
 }
 
 // IsCrossedTheLine - Check if blob crossed the HORIZONTAL line
+// Blob's track of centroids is tested: use CheckObliqueLineCrossingWithAnchor for other points of bounding box
 func (b *KalmanBlobie) IsCrossedTheLine(vertical, leftX, rightX int, direction bool) bool {
-	trackLen := len(b.Track)
-	if b.isStillBeingTracked == true && trackLen >= 2 && b.crossedLine == false {
-		prevFrame := trackLen - 2
-		currFrame := trackLen - 1
-		if b.Track[currFrame].X >= leftX && b.Track[currFrame].X <= rightX {
-			if direction {
-				if b.Track[prevFrame].Y <= vertical && b.Track[currFrame].Y > vertical { // TO us
-					b.crossedLine = true
-					return true
-				}
-			} else {
-				if b.Track[prevFrame].Y > vertical && b.Track[currFrame].Y <= vertical { // FROM us
-					b.crossedLine = true
-					return true
-				}
-			}
-		}
-	}
-	return false
+	return isTrackCrossedTheLine(b.Track, b.isStillBeingTracked, &b.crossedLine, vertical, leftX, rightX, direction, 0)
 }
 
 // IsCrossedTheLineWithShift - Check if blob crossed the HORIZONTAL line with shift along the Y-axis
 // Purpose of this for "predicative" cropping when detection line very close to bottom of image
+// Blob's track of centroids is tested: use CheckObliqueLineCrossingWithAnchor for other points of bounding box
 func (b *KalmanBlobie) IsCrossedTheLineWithShift(vertical, leftX, rightX int, direction bool, shift int) bool {
-	trackLen := len(b.Track)
-	if b.isStillBeingTracked == true && trackLen >= 2 && b.crossedLine == false {
-		prevFrame := trackLen - 2
-		currFrame := trackLen - 1
-		if b.Track[currFrame].X >= leftX && b.Track[currFrame].X <= rightX {
-			if direction {
-				if (b.Track[prevFrame].Y+shift) <= vertical && (b.Track[currFrame].Y+shift) > vertical { // TO us
-					b.crossedLine = true
-					return true
-				}
-			} else {
-				if (b.Track[prevFrame].Y+shift) > vertical && (b.Track[currFrame].Y+shift) <= vertical { // FROM us
-					b.crossedLine = true
-					return true
-				}
-			}
-		}
-	}
-	return false
+	return isTrackCrossedTheLine(b.Track, b.isStillBeingTracked, &b.crossedLine, vertical, leftX, rightX, direction, shift)
 }
 
 // IsCrossedTheObliqueLine - Check if blob crossed the OBLIQUE line
 // This should be used when lineStart.Y != lineEnd.Y
+// Blob's track of centroids is tested
+//
+// Deprecated: direction is decided by comparing Y-coordinates only, which is wrong for near-vertical lines. Use CheckObliqueLineCrossing instead
 func (b *KalmanBlobie) IsCrossedTheObliqueLine(leftX, leftY, rightX, rightY int, direction bool) bool {
-	trackLen := len(b.Track)
-	if b.isStillBeingTracked == true && trackLen >= 2 && b.crossedLine == false {
-		prevFrame := trackLen - 2
-		currFrame := trackLen - 1
-		// First segment is: P1 = (b.Track[prevFrame].X, b.Track[prevFrame].Y), Q1 = (b.Track[currFrame].X, b.Track[currFrame].Y)
-		// Second segment is: P2 = (leftX, leftY), Q2 = (rightX, rightY)
-		if isIntersects(b.Track[prevFrame].X, b.Track[prevFrame].Y, b.Track[currFrame].X, b.Track[currFrame].Y, leftX, leftY, rightX, rightY) {
-
-			if direction {
-				if b.Track[currFrame].Y > b.Track[prevFrame].Y { // TO us
-					b.crossedLine = true
-					return true
-				}
-			} else {
-				if b.Track[currFrame].Y <= b.Track[prevFrame].Y { // FROM us
-					b.crossedLine = true
-					return true
-				}
-			}
-		}
-	}
-	return false
+	return isTrackCrossedTheObliqueLine(b.Track, b.isStillBeingTracked, &b.crossedLine, leftX, leftY, rightX, rightY, direction, 0)
 }
 
-// IsCrossedTheObliqueLine - Check if blob crossed the OBLIQUE line with shift along the Y-axis
+// IsCrossedTheObliqueLineWithShift - Check if blob crossed the OBLIQUE line with shift along the Y-axis
 // This should be used when lineStart.Y != lineEnd.Y
 // Purpose of shifting: for "predicative" cropping when detection line very close to bottom of image
+// Blob's track of centroids is tested
+//
+// Deprecated: direction is decided by comparing Y-coordinates only, which is wrong for near-vertical lines. Use CheckObliqueLineCrossingWithShift instead
 func (b *KalmanBlobie) IsCrossedTheObliqueLineWithShift(leftX, leftY, rightX, rightY int, direction bool, shift int) bool {
-	trackLen := len(b.Track)
-	if b.isStillBeingTracked == true && trackLen >= 2 && b.crossedLine == false {
-		prevFrame := trackLen - 2
-		currFrame := trackLen - 1
-		// First segment is: P1 = (b.Track[prevFrame].X, b.Track[prevFrame].Y + shift), Q1 = (b.Track[currFrame].X, b.Track[currFrame].Y + shift)
-		// Second segment is: P2 = (leftX, leftY), Q2 = (rightX, rightY)
-		if isIntersects(b.Track[prevFrame].X, b.Track[prevFrame].Y+shift, b.Track[currFrame].X, b.Track[currFrame].Y+shift, leftX, leftY, rightX, rightY) {
-			if direction {
-				if b.Track[currFrame].Y > b.Track[prevFrame].Y { // TO us
-					b.crossedLine = true
-					return true
-				}
-			} else {
-				if b.Track[currFrame].Y <= b.Track[prevFrame].Y { // FROM us
-					b.crossedLine = true
-					return true
-				}
-			}
-		}
-	}
-	return false
+	return isTrackCrossedTheObliqueLine(b.Track, b.isStillBeingTracked, &b.crossedLine, leftX, leftY, rightX, rightY, direction, shift)
+}
+
+// IsCrossedTheLine - Check if blob crossed the HORIZONTAL line
+// Blob's track of centroids is tested: use CheckObliqueLineCrossingWithAnchor for other points of bounding box
+func (b *KalmanBBoxBlobie) IsCrossedTheLine(vertical, leftX, rightX int, direction bool) bool {
+	return isTrackCrossedTheLine(b.Track, b.isStillBeingTracked, &b.crossedLine, vertical, leftX, rightX, direction, 0)
+}
+
+// IsCrossedTheLineWithShift - Check if blob crossed the HORIZONTAL line with shift along the Y-axis
+// Purpose of this for "predicative" cropping when detection line very close to bottom of image
+// Blob's track of centroids is tested: use CheckObliqueLineCrossingWithAnchor for other points of bounding box
+func (b *KalmanBBoxBlobie) IsCrossedTheLineWithShift(vertical, leftX, rightX int, direction bool, shift int) bool {
+	return isTrackCrossedTheLine(b.Track, b.isStillBeingTracked, &b.crossedLine, vertical, leftX, rightX, direction, shift)
+}
+
+// IsCrossedTheObliqueLine - Check if blob crossed the OBLIQUE line
+// This should be used when lineStart.Y != lineEnd.Y
+// Blob's track of centroids is tested
+//
+// Deprecated: direction is decided by comparing Y-coordinates only, which is wrong for near-vertical lines. Use CheckObliqueLineCrossing instead
+func (b *KalmanBBoxBlobie) IsCrossedTheObliqueLine(leftX, leftY, rightX, rightY int, direction bool) bool {
+	return isTrackCrossedTheObliqueLine(b.Track, b.isStillBeingTracked, &b.crossedLine, leftX, leftY, rightX, rightY, direction, 0)
+}
+
+// IsCrossedTheObliqueLineWithShift - Check if blob crossed the OBLIQUE line with shift along the Y-axis
+// This should be used when lineStart.Y != lineEnd.Y
+// Purpose of shifting: for "predicative" cropping when detection line very close to bottom of image
+// Blob's track of centroids is tested
+//
+// Deprecated: direction is decided by comparing Y-coordinates only, which is wrong for near-vertical lines. Use CheckObliqueLineCrossingWithShift instead
+func (b *KalmanBBoxBlobie) IsCrossedTheObliqueLineWithShift(leftX, leftY, rightX, rightY int, direction bool, shift int) bool {
+	return isTrackCrossedTheObliqueLine(b.Track, b.isStillBeingTracked, &b.crossedLine, leftX, leftY, rightX, rightY, direction, shift)
 }
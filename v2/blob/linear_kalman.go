@@ -0,0 +1,69 @@
+package blob
+
+import (
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/mat"
+)
+
+// linearKalmanFilter - Linear Kalman filter of arbitrary dimensions without control input. It is used by both KalmanBlobie and KalmanBBoxBlobie
+//
+// A - state transition matrix, H - observation matrix,
+// P - state covariance, Q - process noise covariance, R - observation noise covariance,
+// X - state vector
+type linearKalmanFilter struct {
+	A *mat.Dense
+	H *mat.Dense
+	P *mat.Dense
+	Q *mat.Dense
+	R *mat.Dense
+	X *mat.Dense
+}
+
+// predict - Predict step: X = A * X, P = A * P * A^T + Q
+func (filter *linearKalmanFilter) predict() {
+	var X mat.Dense
+	X.Mul(filter.A, filter.X)
+	filter.X.Copy(&X)
+
+	var AP, APAt mat.Dense
+	AP.Mul(filter.A, filter.P)
+	APAt.Mul(&AP, filter.A.T())
+	filter.P.Add(&APAt, filter.Q)
+}
+
+// update - Update (correction) step for observation z
+func (filter *linearKalmanFilter) update(z *mat.Dense) error {
+	// S = H * P * H^T + R
+	var PHt, S mat.Dense
+	PHt.Mul(filter.P, filter.H.T())
+	S.Mul(filter.H, &PHt)
+	S.Add(&S, filter.R)
+
+	var SInv mat.Dense
+	if err := SInv.Inverse(&S); err != nil {
+		return errors.Wrap(err, "Can't invert innovation covariance")
+	}
+	// K = P * H^T * S^-1
+	var K mat.Dense
+	K.Mul(&PHt, &SInv)
+
+	// X = X + K * (z - H * X)
+	var HX, innovation, correction mat.Dense
+	HX.Mul(filter.H, filter.X)
+	innovation.Sub(z, &HX)
+	correction.Mul(&K, &innovation)
+	filter.X.Add(filter.X, &correction)
+
+	// P = (I - K * H) * P
+	n, _ := filter.P.Dims()
+	identity := mat.NewDiagDense(n, nil)
+	for i := 0; i < n; i++ {
+		identity.SetDiag(i, 1)
+	}
+	var KH, IKH, P mat.Dense
+	KH.Mul(&K, filter.H)
+	IKH.Sub(identity, &KH)
+	P.Mul(&IKH, filter.P)
+	filter.P.Copy(&P)
+	return nil
+}
@@ -0,0 +1,429 @@
+package blob
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ODGateKind - Kind of origin-destination gate
+type ODGateKind int
+
+const (
+	// ODGateLine - Gate is passed when track crosses the line (in any direction)
+	ODGateLine = ODGateKind(iota)
+	// ODGateZone - Gate is passed when track enters the polygon (or when track starts inside of it)
+	ODGateZone
+)
+
+// String - Returns text representation of ODGateKind
+func (kind ODGateKind) String() string {
+	switch kind {
+	case ODGateLine:
+		return "line"
+	case ODGateZone:
+		return "zone"
+	default:
+		return "unknown"
+	}
+}
+
+// TurnDirection - Turning movement of vehicle evaluated from its headings at entry and exit gates
+type TurnDirection int
+
+const (
+	// TurnUnknown - Movement can't be evaluated (e.g. vehicle hasn't moved on the segment where gate has been passed)
+	TurnUnknown = TurnDirection(iota)
+	// TurnThrough - Heading has changed by less than 45 degrees
+	TurnThrough
+	// TurnLeft - Heading has turned counterclockwise (as seen on the image) by 45-135 degrees
+	TurnLeft
+	// TurnRight - Heading has turned clockwise (as seen on the image) by 45-135 degrees
+	TurnRight
+	// TurnU - Heading has changed by more than 135 degrees
+	TurnU
+)
+
+// String - Returns text representation of TurnDirection
+func (turn TurnDirection) String() string {
+	switch turn {
+	case TurnUnknown:
+		return "unknown"
+	case TurnThrough:
+		return "through"
+	case TurnLeft:
+		return "left"
+	case TurnRight:
+		return "right"
+	case TurnU:
+		return "u-turn"
+	default:
+		return "unknown"
+	}
+}
+
+// turnDirection - Evaluates turning movement from entry heading to exit heading
+// Y-axis of image points down, so positive cross product of headings means clockwise (right) turn
+func turnDirection(entry, exit image.Point) TurnDirection {
+	if entry == (image.Point{}) || exit == (image.Point{}) {
+		return TurnUnknown
+	}
+	cross := float64(entry.X*exit.Y - entry.Y*exit.X)
+	dot := float64(entry.X*exit.X + entry.Y*exit.Y)
+	angle := math.Atan2(cross, dot) * 180 / math.Pi
+	switch {
+	case math.Abs(angle) < 45:
+		return TurnThrough
+	case math.Abs(angle) > 135:
+		return TurnU
+	case angle > 0:
+		return TurnRight
+	default:
+		return TurnLeft
+	}
+}
+
+// ODGate - Named gate (e.g. approach of intersection) which tracks enter or leave the scene through
+type ODGate struct {
+	ID   string
+	Kind ODGateKind
+	// Start, End - Segment of ODGateLine gate
+	Start image.Point
+	End   image.Point
+	// Polygon - Region of ODGateZone gate
+	Polygon Polygon
+}
+
+// NewLineGate - Constructor for line gate
+func NewLineGate(id string, start, end image.Point) (ODGate, error) {
+	if start == end {
+		return ODGate{}, fmt.Errorf("start and end of gate '%s' must be different points", id)
+	}
+	return ODGate{ID: id, Kind: ODGateLine, Start: start, End: end}, nil
+}
+
+// NewZoneGate - Constructor for zone gate
+func NewZoneGate(id string, polygon Polygon) (ODGate, error) {
+	if len(polygon) < 3 {
+		return ODGate{}, fmt.Errorf("polygon of gate '%s' must have at least 3 vertices, but got %d", id, len(polygon))
+	}
+	return ODGate{ID: id, Kind: ODGateZone, Polygon: polygon}, nil
+}
+
+// isPassed - Checks if gate is passed on i-th point of track
+func (gate ODGate) isPassed(track []image.Point, i int) bool {
+	switch gate.Kind {
+	case ODGateLine:
+		if i == 0 {
+			return false
+		}
+		prev, curr := track[i-1], track[i]
+		return isIntersects(prev.X, prev.Y, curr.X, curr.Y, gate.Start.X, gate.Start.Y, gate.End.X, gate.End.Y)
+	case ODGateZone:
+		return gate.Polygon.Contains(track[i]) && (i == 0 || !gate.Polygon.Contains(track[i-1]))
+	default:
+		return false
+	}
+}
+
+// ODRecord - Origin and destination of single completed track
+type ODRecord struct {
+	BlobID    uuid.UUID
+	ClassName string
+	Entry     string
+	Exit      string
+	// Turn - Turning movement evaluated from headings of track segments where entry and exit gates have been passed
+	Turn TurnDirection
+	// Movement - Name of turning movement: the one defined by SetMovement for the pair of gates or text representation of Turn otherwise.
+	// Empty when neither of them is known
+	Movement string
+	// EntryTime - Timestamp of track point where entry gate has been passed. It defines time bin of the record
+	EntryTime time.Time
+	// ExitTime - Timestamp of track point where exit gate has been passed
+	ExitTime time.Time
+}
+
+// ODCount - Number of tracks for single cell of origin-destination matrix
+type ODCount struct {
+	TimeBin   time.Time `json:"time_bin"`
+	ClassName string    `json:"class_name"`
+	Entry     string    `json:"entry"`
+	Exit      string    `json:"exit"`
+	Movement  string    `json:"movement"`
+	Count     int       `json:"count"`
+}
+
+// odKey - Key of origin-destination matrix cell
+type odKey struct {
+	timeBin   time.Time
+	className string
+	entry     string
+	exit      string
+	movement  string
+}
+
+// ODMatrix - Origin-destination (turning movement) counter
+//
+// Gates passed by blob are recorded frame by frame by Update (e.g. in OnUpdated handler). Completed blobs (e.g. blobs passed to OnDeregistered handler)
+// are assigned entry gate (the first gate passed along the whole track) and exit gate (the last one) by Complete.
+// Counts are accumulated per class name and per time bin.
+type ODMatrix struct {
+	// BinSize - Duration of time bin. Zero value means that all records are counted in single bin
+	BinSize time.Duration
+	// Anchor - Point of blob's bounding box which is tested against gates. Default is center of bounding box
+	Anchor Anchor
+
+	gates     []ODGate
+	movements map[[2]string]string
+	counts    map[odKey]int
+	states    map[uuid.UUID]*odState
+}
+
+// odPass - Gate passed by blob
+type odPass struct {
+	gate int
+	// hit - Sequence number of blob's track point (see Blobie.Hits()) where gate has been passed
+	hit     int
+	time    time.Time
+	heading image.Point
+}
+
+// odState - Gates passed by single blob so far
+type odState struct {
+	entry  odPass
+	exit   odPass
+	passes int
+	// hits - Number of blob's track points (see Blobie.Hits()) processed so far
+	hits int
+}
+
+// NewODMatrix - Constructor for ODMatrix
+func NewODMatrix(binSize time.Duration) (*ODMatrix, error) {
+	if binSize < 0 {
+		return nil, fmt.Errorf("bin size must be non-negative, but got %s", binSize)
+	}
+	return &ODMatrix{
+		BinSize:   binSize,
+		gates:     []ODGate{},
+		movements: make(map[[2]string]string),
+		counts:    make(map[odKey]int),
+		states:    make(map[uuid.UUID]*odState),
+	}, nil
+}
+
+// AddGate - Adds gate. Identifier of gate must be unique.
+// When several gates are passed on the same track point, the one added earlier is considered to be passed first
+func (od *ODMatrix) AddGate(gate ODGate) error {
+	for i := range od.gates {
+		if od.gates[i].ID == gate.ID {
+			return fmt.Errorf("gate '%s' already exists", gate.ID)
+		}
+	}
+	od.gates = append(od.gates, gate)
+	return nil
+}
+
+// SetMovement - Defines name of turning movement (e.g. "north-left") for pair of entry and exit gates
+// It overrides movement evaluated from track geometry (see ODRecord.Turn) for records completed after the call
+func (od *ODMatrix) SetMovement(entry, exit, name string) {
+	od.movements[[2]string{entry, exit}] = name
+}
+
+// Update - Records gates passed by the blob's track points added since the previous call. It should be called on every frame blob has been updated on
+//
+// The whole retained track is processed on the first call for the blob. Calling it again when no points have been added
+// (e.g. on frame where blob has not been matched) has no effect
+func (od *ODMatrix) Update(b Blobie) {
+	track := od.Anchor.Track(b)
+	if len(track) == 0 {
+		return
+	}
+	timestamps := b.GetTimestamps()
+	if len(timestamps) != len(track) {
+		timestamps = nil
+	}
+	state, ok := od.states[b.GetID()]
+	if !ok {
+		state = &odState{}
+		od.states[b.GetID()] = state
+	}
+	hits := b.Hits()
+	added := hits - state.hits
+	if added <= 0 {
+		return
+	}
+	// Only the very first point processed could start the track inside zone gate
+	if ok && added > len(track)-1 {
+		added = len(track) - 1
+	} else if added > len(track) {
+		added = len(track)
+	}
+	state.hits = hits
+	for i := len(track) - added; i < len(track); i++ {
+		hit := hits - (len(track) - 1 - i)
+		// Gate passed on the very first point of track gets heading of the following segment
+		if i > 0 && state.passes > 0 {
+			for _, pass := range []*odPass{&state.entry, &state.exit} {
+				if pass.hit == hit-1 && pass.heading == (image.Point{}) {
+					pass.heading = track[i].Sub(track[i-1])
+				}
+			}
+		}
+		for g := range od.gates {
+			if !od.gates[g].isPassed(track, i) {
+				continue
+			}
+			pass := odPass{gate: g, hit: hit, heading: passHeading(track, i)}
+			if timestamps != nil {
+				pass.time = timestamps[i]
+			}
+			state.passes++
+			if state.passes == 1 {
+				state.entry = pass
+			}
+			state.exit = pass
+		}
+	}
+}
+
+// Complete - Evaluates gates recorded by Update for completed blob, adds it to the matrix and releases blob's state
+// Second returned value is false when less than two gate passes have been recorded for the blob or when it has been completed already
+func (od *ODMatrix) Complete(b Blobie) (ODRecord, bool) {
+	state, ok := od.states[b.GetID()]
+	if !ok {
+		return ODRecord{}, false
+	}
+	delete(od.states, b.GetID())
+	// Gates passed on the same track segment can't be ordered
+	if state.passes < 2 || state.entry.hit == state.exit.hit {
+		return ODRecord{}, false
+	}
+	record := ODRecord{
+		BlobID:    b.GetID(),
+		ClassName: b.GetClassName(),
+		Entry:     od.gates[state.entry.gate].ID,
+		Exit:      od.gates[state.exit.gate].ID,
+		Turn:      turnDirection(state.entry.heading, state.exit.heading),
+		EntryTime: state.entry.time,
+		ExitTime:  state.exit.time,
+	}
+	record.Movement = od.movements[[2]string{record.Entry, record.Exit}]
+	if record.Movement == "" && record.Turn != TurnUnknown {
+		record.Movement = record.Turn.String()
+	}
+	od.Add(record)
+	return record, true
+}
+
+// passHeading - Returns heading of track segment where gate has been passed on i-th point of track
+// Zone gate could be passed on the very first point of track, so the first segment is used then
+func passHeading(track []image.Point, i int) image.Point {
+	if len(track) < 2 {
+		return image.Point{}
+	}
+	if i == 0 {
+		i = 1
+	}
+	return track[i].Sub(track[i-1])
+}
+
+// Add - Adds record to the matrix (e.g. record evaluated by another ODMatrix)
+func (od *ODMatrix) Add(record ODRecord) {
+	od.counts[odKey{
+		timeBin:   timeBinStart(record.EntryTime, od.BinSize),
+		className: record.ClassName,
+		entry:     record.Entry,
+		exit:      record.Exit,
+		movement:  record.Movement,
+	}]++
+}
+
+// Counts - Returns non-empty cells of the matrix sorted by time bin, class name, entry and exit gates and movement
+func (od *ODMatrix) Counts() []ODCount {
+	counts := make([]ODCount, 0, len(od.counts))
+	for key, count := range od.counts {
+		counts = append(counts, ODCount{
+			TimeBin:   key.timeBin,
+			ClassName: key.className,
+			Entry:     key.entry,
+			Exit:      key.exit,
+			Movement:  key.movement,
+			Count:     count,
+		})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		a, b := counts[i], counts[j]
+		if !a.TimeBin.Equal(b.TimeBin) {
+			return a.TimeBin.Before(b.TimeBin)
+		}
+		if a.ClassName != b.ClassName {
+			return a.ClassName < b.ClassName
+		}
+		if a.Entry != b.Entry {
+			return a.Entry < b.Entry
+		}
+		if a.Exit != b.Exit {
+			return a.Exit < b.Exit
+		}
+		return a.Movement < b.Movement
+	})
+	return counts
+}
+
+// Matrix - Returns number of tracks for each pair of entry and exit gates summed over all time bins: matrix[entry][exit]
+// If className is empty, then all classes are summed too
+func (od *ODMatrix) Matrix(className string) map[string]map[string]int {
+	matrix := make(map[string]map[string]int)
+	for key, count := range od.counts {
+		if className != "" && key.className != className {
+			continue
+		}
+		if _, ok := matrix[key.entry]; !ok {
+			matrix[key.entry] = make(map[string]int)
+		}
+		matrix[key.entry][key.exit] += count
+	}
+	return matrix
+}
+
+// Reset - Removes all accumulated counts (gates and movements are kept)
+func (od *ODMatrix) Reset() {
+	od.counts = make(map[odKey]int)
+}
+
+// WriteCSV - Writes non-empty cells of the matrix (see Counts) as CSV with header
+// Time bin is written in RFC3339 format (empty when BinSize is zero)
+func (od *ODMatrix) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"time_bin", "class_name", "entry", "exit", "movement", "count"}); err != nil {
+		return errors.Wrap(err, "can't write CSV header")
+	}
+	for _, count := range od.Counts() {
+		timeBin := ""
+		if !count.TimeBin.IsZero() {
+			timeBin = count.TimeBin.Format(time.RFC3339)
+		}
+		if err := writer.Write([]string{timeBin, count.ClassName, count.Entry, count.Exit, count.Movement, strconv.Itoa(count.Count)}); err != nil {
+			return errors.Wrap(err, "can't write CSV row")
+		}
+	}
+	writer.Flush()
+	return errors.Wrap(writer.Error(), "can't flush CSV")
+}
+
+// WriteJSON - Writes non-empty cells of the matrix (see Counts) as JSON array
+func (od *ODMatrix) WriteJSON(w io.Writer) error {
+	if err := json.NewEncoder(w).Encode(od.Counts()); err != nil {
+		return errors.Wrap(err, "can't encode JSON")
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package blob
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestODMatrix(t *testing.T) {
+	od, err := NewODMatrix(15 * time.Minute)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// Intersection: approaches from north (line gate) and from west (zone gate), exit to south (line gate)
+	north, err := NewLineGate("north", image.Pt(0, 50), image.Pt(300, 50))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	south, err := NewLineGate("south", image.Pt(0, 250), image.Pt(300, 250))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	west, err := NewZoneGate("west", Polygon{image.Pt(0, 100), image.Pt(40, 100), image.Pt(40, 200), image.Pt(0, 200)})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, gate := range []ODGate{north, south, west} {
+		if err := od.AddGate(gate); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := od.AddGate(north); err == nil {
+		t.Error("Adding gate with the same identifier should produce an error")
+	}
+	od.SetMovement("north", "south", "north-through")
+	od.SetMovement("west", "south", "west-right")
+
+	// Points of tracks are sparse, so gating distance is increased
+	allblobies, err := NewBlobies(WithMinThresholdDistance(100))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	records := []ODRecord{}
+	allblobies.OnUpdated(func(event TrackEvent) { od.Update(event.Blob) })
+	allblobies.OnDeregistered(func(event TrackEvent) {
+		if record, ok := od.Complete(event.Blob); ok {
+			records = append(records, record)
+		}
+	})
+	startTime := time.Date(2021, 1, 1, 8, 10, 0, 0, time.UTC)
+	type path struct {
+		className string
+		points    []image.Point
+	}
+	paths := []path{
+		{"car", []image.Point{{150, 10}, {150, 70}, {150, 130}, {150, 190}, {150, 270}}},
+		{"car", []image.Point{{10, 150}, {60, 160}, {110, 200}, {140, 260}, {150, 290}}},
+		{"bus", []image.Point{{150, 10}, {150, 70}, {150, 130}, {150, 190}, {150, 270}}},
+		// Never reaches exit gate
+		{"car", []image.Point{{150, 10}, {150, 70}, {150, 130}, {150, 140}, {150, 150}}},
+	}
+	frame := 0
+	for i, p := range paths {
+		options := BlobOptions{ClassID: i, ClassName: p.className, MaxPointsInTrack: 20}
+		for _, pt := range p.points {
+			options.Time = startTime.Add(time.Duration(frame) * time.Minute)
+			allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(image.Rect(pt.X-5, pt.Y-5, pt.X+5, pt.Y+5), &options)})
+			frame++
+		}
+		// Let blob disappear
+		for j := 0; j <= allblobies.maxNoMatch; j++ {
+			allblobies.MatchToExistingWithTime([]Blobie{}, options.Time)
+		}
+	}
+	if len(records) != 3 {
+		t.Errorf("Number of OD records should be %d, but got %d", 3, len(records))
+		return
+	}
+	if records[1].Entry != "west" || records[1].Exit != "south" || records[1].Movement != "west-right" {
+		t.Errorf("Second record should be from 'west' to 'south' ('west-right'), but got %+v", records[1])
+	}
+
+	counts := od.Counts()
+	correct := []ODCount{
+		{TimeBin: time.Date(2021, 1, 1, 8, 0, 0, 0, time.UTC), ClassName: "car", Entry: "north", Exit: "south", Movement: "north-through", Count: 1},
+		{TimeBin: time.Date(2021, 1, 1, 8, 15, 0, 0, time.UTC), ClassName: "bus", Entry: "north", Exit: "south", Movement: "north-through", Count: 1},
+		{TimeBin: time.Date(2021, 1, 1, 8, 15, 0, 0, time.UTC), ClassName: "car", Entry: "west", Exit: "south", Movement: "west-right", Count: 1},
+	}
+	if len(counts) != len(correct) {
+		t.Errorf("Number of OD cells should be %d, but got %d: %+v", len(correct), len(counts), counts)
+		return
+	}
+	for i := range correct {
+		if counts[i] != correct[i] {
+			t.Errorf("OD cell #%d should be %+v, but got %+v", i, correct[i], counts[i])
+		}
+	}
+	if matrix := od.Matrix(""); matrix["north"]["south"] != 2 {
+		t.Errorf("Number of tracks from 'north' to 'south' should be %d, but got %d", 2, matrix["north"]["south"])
+	}
+
+	csvBuf := bytes.Buffer{}
+	if err := od.WriteCSV(&csvBuf); err != nil {
+		t.Error(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvBuf.String()), "\n")
+	if len(lines) != 4 || lines[1] != "2021-01-01T08:00:00Z,car,north,south,north-through,1" {
+		t.Errorf("Wrong CSV output:\n%s", csvBuf.String())
+	}
+	jsonBuf := bytes.Buffer{}
+	if err := od.WriteJSON(&jsonBuf); err != nil {
+		t.Error(err)
+	}
+	decoded := []ODCount{}
+	if err := json.Unmarshal(jsonBuf.Bytes(), &decoded); err != nil {
+		t.Error(err)
+	}
+	if len(decoded) != len(correct) || decoded[2].Movement != "west-right" {
+		t.Errorf("Wrong JSON output: %s", jsonBuf.String())
+	}
+}
+
+func TestODMatrixTurns(t *testing.T) {
+	od, err := NewODMatrix(0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// Intersection: approaches from north and from west, exits to south and to east. No movements are defined explicitly
+	lines := []struct {
+		id         string
+		start, end image.Point
+	}{
+		{"north", image.Pt(0, 50), image.Pt(300, 50)},
+		{"south", image.Pt(0, 250), image.Pt(300, 250)},
+		{"east", image.Pt(260, 0), image.Pt(260, 300)},
+	}
+	for _, l := range lines {
+		gate, err := NewLineGate(l.id, l.start, l.end)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if err := od.AddGate(gate); err != nil {
+			t.Error(err)
+		}
+	}
+	west, err := NewZoneGate("west", Polygon{image.Pt(0, 100), image.Pt(40, 100), image.Pt(40, 200), image.Pt(0, 200)})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err := od.AddGate(west); err != nil {
+		t.Error(err)
+	}
+	cases := []struct {
+		points  []image.Point
+		correct TurnDirection
+	}{
+		{[]image.Point{{150, 10}, {150, 70}, {150, 130}, {150, 190}, {150, 270}}, TurnThrough},
+		{[]image.Point{{10, 150}, {60, 160}, {110, 200}, {140, 260}, {150, 290}}, TurnRight},
+		{[]image.Point{{150, 10}, {150, 70}, {160, 130}, {220, 150}, {280, 150}}, TurnLeft},
+		{[]image.Point{{10, 150}, {100, 150}, {200, 150}, {280, 150}}, TurnThrough},
+	}
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 20}
+	for i, c := range cases {
+		b := NewSimpleBlobie(image.Rect(c.points[0].X-5, c.points[0].Y-5, c.points[0].X+5, c.points[0].Y+5), &options)
+		od.Update(b)
+		for _, pt := range c.points[1:] {
+			b.Update(NewSimpleBlobie(image.Rect(pt.X-5, pt.Y-5, pt.X+5, pt.Y+5), &options))
+			od.Update(b)
+		}
+		record, ok := od.Complete(b)
+		if !ok {
+			t.Errorf("Case #%d: track should be completed", i)
+			continue
+		}
+		if record.Turn != c.correct || record.Movement != c.correct.String() {
+			t.Errorf("Case #%d: movement from '%s' to '%s' should be '%s', but got '%s' ('%s')", i, record.Entry, record.Exit, c.correct, record.Turn, record.Movement)
+		}
+	}
+	// Explicitly defined movement overrides evaluated one
+	od.SetMovement("north", "south", "north-through")
+	b := NewSimpleBlobie(image.Rect(145, 5, 155, 15), &options)
+	b.Update(NewSimpleBlobie(image.Rect(145, 135, 155, 145), &options))
+	b.Update(NewSimpleBlobie(image.Rect(145, 265, 155, 275), &options))
+	od.Update(b)
+	if record, ok := od.Complete(b); !ok || record.Turn != TurnThrough || record.Movement != "north-through" {
+		t.Errorf("Movement should be 'north-through', but got %+v", record)
+	}
+	if matrix := od.Matrix(""); matrix["north"]["south"] != 2 || matrix["north"]["east"] != 1 {
+		t.Errorf("Wrong OD matrix: %v", matrix)
+	}
+}
+
+func TestODMatrixShortTrack(t *testing.T) {
+	od, err := NewODMatrix(0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, y := range []int{50, 250} {
+		gate, err := NewLineGate(strconv.Itoa(y), image.Pt(0, y), image.Pt(300, y))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if err := od.AddGate(gate); err != nil {
+			t.Error(err)
+		}
+	}
+	// Track keeps just a few points, so both gates are never present in it at once
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 3, Time: startTime}
+	b := NewSimpleBlobie(image.Rect(145, 10, 155, 20), &options)
+	od.Update(b)
+	for i := 1; i <= 10; i++ {
+		options.Time = startTime.Add(time.Duration(i) * time.Second)
+		y := 15 + i*30
+		b.Update(NewSimpleBlobie(image.Rect(145, y-5, 155, y+5), &options))
+		od.Update(b)
+		// Repeated call for the same track point must not record gates twice
+		od.Update(b)
+	}
+	record, ok := od.Complete(b)
+	if !ok || record.Entry != "50" || record.Exit != "250" || record.Turn != TurnThrough {
+		t.Errorf("Track should be completed from '%s' to '%s' ('%s'), but got %+v", "50", "250", TurnThrough, record)
+	}
+	if correctTime := startTime.Add(2 * time.Second); !record.EntryTime.Equal(correctTime) {
+		t.Errorf("Entry time should be %s, but got %s", correctTime, record.EntryTime)
+	}
+	if correctTime := startTime.Add(8 * time.Second); !record.ExitTime.Equal(correctTime) {
+		t.Errorf("Exit time should be %s, but got %s", correctTime, record.ExitTime)
+	}
+	// State of blob is released, so it can't be counted twice
+	if _, ok := od.Complete(b); ok {
+		t.Error("Blob should not be completed twice")
+	}
+	if matrix := od.Matrix(""); matrix["50"]["250"] != 1 {
+		t.Errorf("Number of tracks from '%s' to '%s' should be %d, but got %d", "50", "250", 1, matrix["50"]["250"])
+	}
+}
@@ -11,4 +11,9 @@ type BlobOptions struct {
 	MaxPointsInTrack int
 	Time             time.Time
 	TimeDeltaSeconds float64
+	// DeriveTimeDelta - If true, then Kalman filter-based blobs derive time step from timestamps of consecutive detections (see Time)
+	// and rebuild transition and process noise matrices on each update. TimeDeltaSeconds is still used for predictions on frames without detections
+	DeriveTimeDelta bool
+	// Speed - Options for speed estimation (see GetSpeed). If nil, then DefaultSpeedOptions() are used
+	Speed *SpeedOptions
 }
@@ -0,0 +1,206 @@
+package blob
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/LdDl/gocv-blob/v2/calibration"
+	uuid "github.com/satori/go.uuid"
+)
+
+// QueueState - Queue on the lane at single frame
+type QueueState struct {
+	LaneID string
+	Time   time.Time
+	// Vehicles - Number of queued vehicles
+	Vehicles int
+	// BlobIDs - Identifiers of queued vehicles sorted by distance to the stop line
+	BlobIDs []uuid.UUID
+	// LengthPixels - Distance (in pixels) from the stop line to the farthest point of bounding boxes of queued vehicles
+	LengthPixels float64
+	// Calibrated - True when LengthMeters has been evaluated (ground calibration is attached)
+	Calibrated bool
+	// LengthMeters - Distance (in meters) from the stop line to the farthest point of bounding boxes of queued vehicles
+	LengthMeters float64
+}
+
+// QueueCycleStats - Aggregated queue statistics over single cycle (e.g. signal cycle)
+type QueueCycleStats struct {
+	LaneID string
+	// Start, End - Timestamps of the first and the last frames of cycle
+	Start time.Time
+	End   time.Time
+	// Frames - Number of frames in cycle
+	Frames          int
+	MaxVehicles     int
+	AverageVehicles float64
+	MaxLengthPixels float64
+	MaxLengthMeters float64
+}
+
+// QueueAnalyzer - Estimator of queue length behind the stop line of the lane
+//
+// Vehicle is considered to be queued when its anchor point is inside of the lane polygon and upstream of the stop line, and its smoothed speed (see Blobie.GetSpeed) is below MaxSpeed.
+// Vehicles which speed can't be estimated yet are not considered to be queued
+type QueueAnalyzer struct {
+	ID      string
+	Polygon Polygon
+	// StopStart, StopEnd - Stop line of the lane. It is directed so that upstream part of the lane (where vehicles are queued) is on its left side
+	// as seen from StopStart to StopEnd, i.e. vehicles cross it in CrossingForward direction when they leave the queue
+	StopStart image.Point
+	StopEnd   image.Point
+	// MaxSpeed - Maximum smoothed speed (in pixels per second) of queued vehicle
+	MaxSpeed float64
+	// Anchor - Point of blob's bounding box which is tested against the lane polygon. Default is center of bounding box
+	Anchor Anchor
+	// Calibration - Homography which maps image points to ground points in meters. If nil, then only length in pixels is evaluated
+	Calibration *calibration.Homography
+
+	cycle QueueCycleStats
+	// cycleVehicles - Sum of queued vehicles over frames of current cycle
+	cycleVehicles int
+}
+
+// NewQueueAnalyzer - Constructor for QueueAnalyzer
+func NewQueueAnalyzer(id string, polygon Polygon, stopStart, stopEnd image.Point, maxSpeed float64) (*QueueAnalyzer, error) {
+	if len(polygon) < 3 {
+		return nil, fmt.Errorf("polygon of lane '%s' must have at least 3 vertices, but got %d", id, len(polygon))
+	}
+	if stopStart == stopEnd {
+		return nil, fmt.Errorf("start and end of stop line of lane '%s' must be different points", id)
+	}
+	if maxSpeed <= 0 {
+		return nil, fmt.Errorf("maximum speed of queued vehicle on lane '%s' must be positive, but got %f", id, maxSpeed)
+	}
+	q := &QueueAnalyzer{
+		ID:        id,
+		Polygon:   polygon,
+		StopStart: stopStart,
+		StopEnd:   stopEnd,
+		MaxSpeed:  maxSpeed,
+	}
+	q.resetCycle()
+	return q, nil
+}
+
+// queuedVehicle - Queued vehicle and its distance to the stop line
+type queuedVehicle struct {
+	id       uuid.UUID
+	pixels   float64
+	meters   float64
+	distance float64
+}
+
+// Update - Evaluates queue on the frame and accumulates statistics of current cycle
+//
+// objects - blobs currently being tracked (e.g. Blobies.Objects or Blobies.ObjectsInState(...))
+func (q *QueueAnalyzer) Update(objects map[uuid.UUID]Blobie, frameTime time.Time) QueueState {
+	state := QueueState{
+		LaneID:     q.ID,
+		Time:       frameTime,
+		BlobIDs:    []uuid.UUID{},
+		Calibrated: q.Calibration != nil,
+	}
+	stopStart, stopEnd := calibration.NewPointFromImage(q.StopStart), calibration.NewPointFromImage(q.StopEnd)
+	var groundStart, groundEnd calibration.Point
+	if state.Calibrated {
+		var errStart, errEnd error
+		groundStart, errStart = q.Calibration.ProjectImagePoint(q.StopStart)
+		groundEnd, errEnd = q.Calibration.ProjectImagePoint(q.StopEnd)
+		state.Calibrated = errStart == nil && errEnd == nil
+	}
+	// Upstream side is the left one, so distance to the stop line is negated signed distance
+	upstreamDistance := func(pt image.Point) float64 {
+		return -signedLineDistance(calibration.NewPointFromImage(pt), stopStart, stopEnd)
+	}
+	queued := []queuedVehicle{}
+	for id, b := range objects {
+		track := q.Anchor.Track(b)
+		if len(track) == 0 {
+			continue
+		}
+		anchor := track[len(track)-1]
+		if !q.Polygon.Contains(anchor) || upstreamDistance(anchor) <= 0 {
+			continue
+		}
+		speed, ok := b.GetSpeed()
+		if !ok || speed.SmoothedPixelsPerSecond >= q.MaxSpeed {
+			continue
+		}
+		vehicle := queuedVehicle{id: id, distance: upstreamDistance(anchor)}
+		for _, corner := range rectCorners(b.GetCurrentRect()) {
+			pixels := upstreamDistance(corner)
+			// Part of bounding box which is beyond the stop line doesn't make queue longer
+			if pixels <= 0 {
+				continue
+			}
+			vehicle.pixels = math.Max(vehicle.pixels, pixels)
+			if state.Calibrated {
+				groundPt, err := q.Calibration.ProjectImagePoint(corner)
+				if err != nil {
+					state.Calibrated = false
+					continue
+				}
+				vehicle.meters = math.Max(vehicle.meters, math.Abs(signedLineDistance(groundPt, groundStart, groundEnd)))
+			}
+		}
+		queued = append(queued, vehicle)
+	}
+	sort.Slice(queued, func(i, j int) bool {
+		return queued[i].distance < queued[j].distance
+	})
+	for _, vehicle := range queued {
+		state.BlobIDs = append(state.BlobIDs, vehicle.id)
+		state.LengthPixels = math.Max(state.LengthPixels, vehicle.pixels)
+		state.LengthMeters = math.Max(state.LengthMeters, vehicle.meters)
+	}
+	state.Vehicles = len(queued)
+	if !state.Calibrated {
+		state.LengthMeters = 0
+	}
+	q.accumulate(state)
+	return state
+}
+
+// EndCycle - Returns statistics of current cycle and starts new one (e.g. should be called when signal turns green)
+func (q *QueueAnalyzer) EndCycle() QueueCycleStats {
+	stats := q.cycle
+	if stats.Frames > 0 {
+		stats.AverageVehicles = float64(q.cycleVehicles) / float64(stats.Frames)
+	}
+	q.resetCycle()
+	return stats
+}
+
+func (q *QueueAnalyzer) accumulate(state QueueState) {
+	if q.cycle.Frames == 0 {
+		q.cycle.Start = state.Time
+	}
+	q.cycle.End = state.Time
+	q.cycle.Frames++
+	q.cycleVehicles += state.Vehicles
+	if state.Vehicles > q.cycle.MaxVehicles {
+		q.cycle.MaxVehicles = state.Vehicles
+	}
+	q.cycle.MaxLengthPixels = math.Max(q.cycle.MaxLengthPixels, state.LengthPixels)
+	q.cycle.MaxLengthMeters = math.Max(q.cycle.MaxLengthMeters, state.LengthMeters)
+}
+
+func (q *QueueAnalyzer) resetCycle() {
+	q.cycle = QueueCycleStats{LaneID: q.ID}
+	q.cycleVehicles = 0
+}
+
+// signedLineDistance - Returns signed distance from point to the (infinite) line defined by two different points
+// Sign is the same as for sideOfLine: positive value means that point is on the right side of directed line (in image coordinates)
+func signedLineDistance(pt, start, end calibration.Point) float64 {
+	dx, dy := end.X-start.X, end.Y-start.Y
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return calibration.Distance(pt, start)
+	}
+	return (dx*(pt.Y-start.Y) - dy*(pt.X-start.X)) / length
+}
@@ -0,0 +1,67 @@
+package blob
+
+import (
+	"image"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/LdDl/gocv-blob/v2/calibration"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestQueueAnalyzer(t *testing.T) {
+	// Lane polygon spans beyond the stop line. Vehicles move up, so the stop line is directed from right to left
+	queue, err := NewQueueAnalyzer("northbound", Polygon{image.Pt(0, 0), image.Pt(100, 0), image.Pt(100, 500), image.Pt(0, 500)}, image.Pt(100, 100), image.Pt(0, 100), 20)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// Scale: 10 pixels per meter
+	queue.Calibration, err = calibration.NewHomographyFromMatrix([9]float64{0.1, 0, 0, 0, 0.1, 0, 0, 0, 1})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10, Time: startTime}
+	// Two standing vehicles behind the stop line, one vehicle approaching the queue and one standing vehicle beyond the stop line (still inside of the lane polygon)
+	rects := []image.Rectangle{
+		image.Rect(30, 110, 70, 150),
+		image.Rect(30, 160, 70, 200),
+		image.Rect(30, 400, 70, 440),
+		image.Rect(30, 40, 70, 80),
+	}
+	velocities := []int{0, 1, -100, 0}
+	objects := map[uuid.UUID]Blobie{}
+	blobs := make([]Blobie, len(rects))
+	for i := range rects {
+		blobs[i] = NewSimpleBlobie(rects[i], &options)
+		blobs[i].SetID(uuid.NewV4())
+		objects[blobs[i].GetID()] = blobs[i]
+	}
+	states := []QueueState{}
+	for frame := 1; frame <= 4; frame++ {
+		options.Time = startTime.Add(time.Duration(frame) * 100 * time.Millisecond)
+		for i := range blobs {
+			blobs[i].Update(NewSimpleBlobie(rects[i].Add(image.Pt(0, velocities[i]*frame/10)), &options))
+		}
+		states = append(states, queue.Update(objects, options.Time))
+	}
+	last := states[len(states)-1]
+	if last.Vehicles != 2 || last.BlobIDs[0] != blobs[0].GetID() || last.BlobIDs[1] != blobs[1].GetID() {
+		t.Errorf("Queue should contain first two vehicles, but got %d vehicles: %v", last.Vehicles, last.BlobIDs)
+	}
+	if math.Abs(last.LengthPixels-100) > 1e-9 || !last.Calibrated || math.Abs(last.LengthMeters-10) > 1e-9 {
+		t.Errorf("Queue length should be %f px (%f m), but got %f px (%f m)", 100.0, 10.0, last.LengthPixels, last.LengthMeters)
+	}
+
+	stats := queue.EndCycle()
+	if stats.Frames != 4 || stats.MaxVehicles != 2 || math.Abs(stats.AverageVehicles-2) > 1e-9 || !stats.Start.Equal(startTime.Add(100*time.Millisecond)) {
+		t.Errorf("Wrong cycle statistics: %+v", stats)
+	}
+	if stats = queue.EndCycle(); stats.Frames != 0 {
+		t.Errorf("New cycle should be empty, but got %+v", stats)
+	}
+}
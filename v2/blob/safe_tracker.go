@@ -0,0 +1,132 @@
+package blob
+
+import (
+	"image"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// TrackView - Read-only copy of blob's state. It is safe to use it after tracker has been updated
+type TrackView struct {
+	ID                    uuid.UUID
+	ClassID               int
+	ClassName             string
+	State                 TrackState
+	CurrentRect           image.Rectangle
+	Center                image.Point
+	PredictedNextPosition image.Point
+	Track                 []image.Point
+	RectTrack             []image.Rectangle
+	TrackTime             []time.Time
+	Diagonal              float64
+	NoMatchTimes          int
+	Hits                  int
+	Age                   int
+}
+
+// NewTrackView - Creates copy of blob's state
+func NewTrackView(b Blobie) TrackView {
+	track := make([]image.Point, len(b.GetTrack()))
+	copy(track, b.GetTrack())
+	rectTrack := make([]image.Rectangle, len(b.GetRectTrack()))
+	copy(rectTrack, b.GetRectTrack())
+	trackTime := make([]time.Time, len(b.GetTimestamps()))
+	copy(trackTime, b.GetTimestamps())
+	return TrackView{
+		ID:                    b.GetID(),
+		ClassID:               b.GetClassID(),
+		ClassName:             b.GetClassName(),
+		State:                 b.GetState(),
+		CurrentRect:           b.GetCurrentRect(),
+		Center:                b.GetCenter(),
+		PredictedNextPosition: b.GetPredictedNextPosition(),
+		Track:                 track,
+		RectTrack:             rectTrack,
+		TrackTime:             trackTime,
+		Diagonal:              b.GetDiagonal(),
+		NoMatchTimes:          b.NoMatchTimes(),
+		Hits:                  b.Hits(),
+		Age:                   b.Age(),
+	}
+}
+
+// SafeBlobies - Goroutine-safe wrapper around Blobies
+//
+// Updates are done under exclusive lock, while read accessors share the lock and return copies of blobs' states (see TrackView).
+// Note: track event handlers (see OnRegistered and etc.) are called while exclusive lock is held, so they must not call methods of SafeBlobies
+type SafeBlobies struct {
+	mu      sync.RWMutex
+	blobies *Blobies
+}
+
+// NewSafeBlobies - Constructor for SafeBlobies. Provided Blobies should not be used directly after that
+func NewSafeBlobies(blobies *Blobies) *SafeBlobies {
+	return &SafeBlobies{
+		blobies: blobies,
+	}
+}
+
+// MatchToExisting - Goroutine-safe version of Blobies.MatchToExisting
+func (sb *SafeBlobies) MatchToExisting(blobies []Blobie) error {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.blobies.MatchToExisting(blobies)
+}
+
+// MatchToExistingWithTime - Goroutine-safe version of Blobies.MatchToExistingWithTime
+func (sb *SafeBlobies) MatchToExistingWithTime(blobies []Blobie, frameTime time.Time) error {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.blobies.MatchToExistingWithTime(blobies, frameTime)
+}
+
+// Do - Executes provided function with exclusive access to underlying Blobies (e.g. for subscribing to events or drawing)
+// Reference to Blobies (or its blobs) must not be kept after function returns
+func (sb *SafeBlobies) Do(fn func(bt *Blobies)) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	fn(sb.blobies)
+}
+
+// Len - Returns number of registered blobs
+func (sb *SafeBlobies) Len() int {
+	sb.mu.RLock()
+	defer sb.mu.RUnlock()
+	return len(sb.blobies.Objects)
+}
+
+// Get - Returns copy of blob's state by its identifier
+func (sb *SafeBlobies) Get(id uuid.UUID) (TrackView, bool) {
+	sb.mu.RLock()
+	defer sb.mu.RUnlock()
+	b, ok := sb.blobies.Objects[id]
+	if !ok {
+		return TrackView{}, false
+	}
+	return NewTrackView(b), true
+}
+
+// Snapshot - Returns copies of states of every registered blob
+func (sb *SafeBlobies) Snapshot() []TrackView {
+	sb.mu.RLock()
+	defer sb.mu.RUnlock()
+	views := make([]TrackView, 0, len(sb.blobies.Objects))
+	for _, b := range sb.blobies.Objects {
+		views = append(views, NewTrackView(b))
+	}
+	return views
+}
+
+// SnapshotInState - Returns copies of states of registered blobs which are in one of provided states
+func (sb *SafeBlobies) SnapshotInState(states ...TrackState) []TrackView {
+	sb.mu.RLock()
+	defer sb.mu.RUnlock()
+	filtered := sb.blobies.ObjectsInState(states...)
+	views := make([]TrackView, 0, len(filtered))
+	for _, b := range filtered {
+		views = append(views, NewTrackView(b))
+	}
+	return views
+}
@@ -0,0 +1,83 @@
+package blob
+
+import (
+	"image"
+	"sync"
+	"testing"
+)
+
+// Run with -race flag to check for data races
+func TestSafeBlobiesConcurrentAccess(t *testing.T) {
+	allblobies := NewSafeBlobies(NewBlobiesDefaults())
+	rectHalfHeight := 30
+	rectHalfWidth := 75
+	commonOptions := BlobOptions{
+		ClassID:          1,
+		ClassName:        "just_an_object",
+		MaxPointsInTrack: 150,
+	}
+
+	wg := sync.WaitGroup{}
+	done := make(chan struct{})
+
+	// Readers
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				views := allblobies.Snapshot()
+				for _, view := range views {
+					if len(view.Track) == 0 {
+						t.Errorf("Track of blob %s should not be empty", view.ID)
+					}
+					if _, ok := allblobies.Get(view.ID); !ok {
+						// Blob could be deregistered already, it is fine
+						continue
+					}
+				}
+				_ = allblobies.SnapshotInState(TrackConfirmed)
+				_ = allblobies.Len()
+			}
+		}()
+	}
+
+	// Writer
+	for i := range objectOne {
+		centerOne := objectOne[i]
+		centerTwo := objectTwo[i]
+		rectOne := image.Rect(centerOne[0]-rectHalfWidth, centerOne[1]-rectHalfHeight, centerOne[0]+rectHalfWidth, centerOne[1]+rectHalfHeight)
+		rectTwo := image.Rect(centerTwo[0]-rectHalfWidth, centerTwo[1]-rectHalfHeight, centerTwo[0]+rectHalfWidth, centerTwo[1]+rectHalfHeight)
+		allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(rectOne, &commonOptions), NewSimpleBlobie(rectTwo, &commonOptions)})
+	}
+	close(done)
+	wg.Wait()
+
+	if allblobies.Len() != 2 {
+		t.Errorf("Total number of blobs should be %d, but got %d", 2, allblobies.Len())
+	}
+}
+
+func TestTrackViewIsCopy(t *testing.T) {
+	allblobies := NewSafeBlobies(NewBlobiesDefaults())
+	rect := image.Rect(100, 100, 140, 140)
+	allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(rect, nil)})
+	views := allblobies.Snapshot()
+	if len(views) != 1 {
+		t.Errorf("Number of views should be %d, but got %d", 1, len(views))
+		return
+	}
+	views[0].Track[0] = image.Pt(-1, -1)
+	allblobies.Do(func(bt *Blobies) {
+		for _, b := range bt.Objects {
+			if b.GetTrack()[0] == image.Pt(-1, -1) {
+				t.Error("Modification of view should not affect blob")
+			}
+		}
+	})
+}
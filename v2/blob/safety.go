@@ -0,0 +1,306 @@
+package blob
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Severity - Severity of near-miss
+type Severity int
+
+const (
+	// SeverityLow - Surrogate measure is below threshold
+	SeverityLow = Severity(iota)
+	// SeverityMedium - Surrogate measure is below 2/3 of threshold
+	SeverityMedium
+	// SeverityHigh - Surrogate measure is below 1/3 of threshold
+	SeverityHigh
+)
+
+// String - Returns text representation of Severity
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityLow:
+		return "low"
+	case SeverityMedium:
+		return "medium"
+	case SeverityHigh:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+// SafetyMeasures - Surrogate safety measures for pair of concurrent tracks
+//
+// Blobs are assumed to keep their current velocities. Bounding boxes are used as footprints of blobs
+type SafetyMeasures struct {
+	// HasTTC - True when blobs approach each other and their bounding boxes are predicted to overlap within prediction horizon.
+	// It is false for overlapping bounding boxes which centers are not getting closer (e.g. parked or diverging vehicles)
+	HasTTC bool
+	// TTC - Time-to-collision: time after which bounding boxes are predicted to overlap (zero if they overlap already and keep approaching)
+	TTC time.Duration
+	// MinDistance - Minimum predicted distance (in pixels) between bounding boxes within prediction horizon
+	MinDistance float64
+	// TimeToMinDistance - Time after which MinDistance is predicted to be reached
+	TimeToMinDistance time.Duration
+	// HasPET - True when one of blobs occupies area which another one has left recently (see Blobie.GetRectTrack())
+	HasPET bool
+	// PET - Post-encroachment time: time passed between the first blob leaving the area and the second one entering it
+	PET time.Duration
+}
+
+// NearMissEvent - Event of near-miss between two blobs
+type NearMissEvent struct {
+	BlobA  uuid.UUID
+	BlobB  uuid.UUID
+	ClassA string
+	ClassB string
+	// Time - Timestamp of frame on which near-miss has been detected
+	Time     time.Time
+	Measures SafetyMeasures
+	Severity Severity
+}
+
+// SafetyAnalyzer - Detector of near-misses between concurrent tracks based on time-to-collision (TTC) and post-encroachment time (PET)
+//
+// Near-miss is reported once per episode for each pair of blobs: when TTC or PET falls below threshold.
+// Episode ends when both measures are above thresholds again
+type SafetyAnalyzer struct {
+	// TTCThreshold - Near-miss is reported when TTC is below this value. Zero value disables TTC-based detection
+	TTCThreshold time.Duration
+	// PETThreshold - Near-miss is reported when PET is below this value. Zero value disables PET-based detection
+	PETThreshold time.Duration
+	// Horizon - Prediction horizon for TTC and minimum distance
+	Horizon time.Duration
+	// VelocityWindow - Number of last track points which velocity of blob is evaluated for
+	VelocityWindow int
+
+	reported map[[2]uuid.UUID]bool
+}
+
+// NewSafetyAnalyzer - Constructor for SafetyAnalyzer
+//
+// Default values are:
+// Horizon = 3s
+// VelocityWindow = 5
+func NewSafetyAnalyzer(ttcThreshold, petThreshold time.Duration) (*SafetyAnalyzer, error) {
+	if ttcThreshold < 0 || petThreshold < 0 {
+		return nil, fmt.Errorf("thresholds must be non-negative, but got TTC %s and PET %s", ttcThreshold, petThreshold)
+	}
+	if ttcThreshold == 0 && petThreshold == 0 {
+		return nil, fmt.Errorf("at least one of TTC and PET thresholds must be positive")
+	}
+	return &SafetyAnalyzer{
+		TTCThreshold:   ttcThreshold,
+		PETThreshold:   petThreshold,
+		Horizon:        3 * time.Second,
+		VelocityWindow: 5,
+		reported:       make(map[[2]uuid.UUID]bool),
+	}, nil
+}
+
+// Update - Evaluates every pair of blobs and returns near-misses happened on the frame (sorted by identifiers of blobs)
+//
+// objects - blobs currently being tracked (e.g. Blobies.Objects or Blobies.ObjectsInState(...))
+func (s *SafetyAnalyzer) Update(objects map[uuid.UUID]Blobie, frameTime time.Time) []NearMissEvent {
+	ids := make([]uuid.UUID, 0, len(objects))
+	for id := range objects {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].String() < ids[j].String()
+	})
+	events := []NearMissEvent{}
+	active := make(map[[2]uuid.UUID]bool)
+	for i := range ids {
+		for j := i + 1; j < len(ids); j++ {
+			a, b := objects[ids[i]], objects[ids[j]]
+			measures, ok := s.Measure(a, b)
+			if !ok {
+				continue
+			}
+			severity, isNearMiss := s.severity(measures)
+			if !isNearMiss {
+				continue
+			}
+			pair := [2]uuid.UUID{ids[i], ids[j]}
+			active[pair] = true
+			if s.reported[pair] {
+				continue
+			}
+			events = append(events, NearMissEvent{
+				BlobA:    ids[i],
+				BlobB:    ids[j],
+				ClassA:   a.GetClassName(),
+				ClassB:   b.GetClassName(),
+				Time:     frameTime,
+				Measures: measures,
+				Severity: severity,
+			})
+		}
+	}
+	s.reported = active
+	return events
+}
+
+// Measure - Evaluates surrogate safety measures for pair of blobs
+// Second returned value is false when velocity of any blob can't be evaluated
+func (s *SafetyAnalyzer) Measure(a, b Blobie) (SafetyMeasures, bool) {
+	vxA, vyA, okA := trackVelocity(a, s.VelocityWindow)
+	vxB, vyB, okB := trackVelocity(b, s.VelocityWindow)
+	if !okA || !okB {
+		return SafetyMeasures{}, false
+	}
+	rectA, rectB := a.GetCurrentRect(), b.GetCurrentRect()
+	cxA, cyA := rectCenter(rectA)
+	cxB, cyB := rectCenter(rectB)
+	// Motion of box A relative to box B
+	dx, dy := cxA-cxB, cyA-cyB
+	vx, vy := vxA-vxB, vyA-vyB
+	sx, sy := float64(rectA.Dx()+rectB.Dx())/2, float64(rectA.Dy()+rectB.Dy())/2
+	horizon := s.Horizon.Seconds()
+
+	measures := SafetyMeasures{}
+	fromX, toX := overlapInterval(dx, vx, sx)
+	fromY, toY := overlapInterval(dy, vy, sy)
+	from, to := math.Max(math.Max(fromX, fromY), 0), math.Min(math.Min(toX, toY), horizon)
+	// Boxes which overlap already are in conflict only when they keep closing on each other (e.g. parked vehicles with overlapping boxes are not)
+	if from <= to && (from > 0 || dx*vx+dy*vy < 0) {
+		measures.HasTTC = true
+		measures.TTC = secondsToDuration(from)
+	}
+
+	gap := func(t float64) float64 {
+		gapX := math.Max(0, math.Abs(dx+vx*t)-sx)
+		gapY := math.Max(0, math.Abs(dy+vy*t)-sy)
+		return math.Hypot(gapX, gapY)
+	}
+	// Distance between boxes is convex function of time, so ternary search is used
+	lo, hi := 0.0, horizon
+	for iter := 0; iter < 100; iter++ {
+		m1, m2 := lo+(hi-lo)/3, hi-(hi-lo)/3
+		if gap(m1) <= gap(m2) {
+			hi = m2
+		} else {
+			lo = m1
+		}
+	}
+	measures.MinDistance = gap(lo)
+	measures.TimeToMinDistance = secondsToDuration(lo)
+	if measures.HasTTC {
+		measures.MinDistance = 0
+		measures.TimeToMinDistance = measures.TTC
+	}
+
+	petAB, okAB := postEncroachmentTime(a, b)
+	petBA, okBA := postEncroachmentTime(b, a)
+	switch {
+	case okAB && okBA:
+		measures.HasPET = true
+		measures.PET = petAB
+		if petBA < petAB {
+			measures.PET = petBA
+		}
+	case okAB:
+		measures.HasPET, measures.PET = true, petAB
+	case okBA:
+		measures.HasPET, measures.PET = true, petBA
+	}
+	return measures, true
+}
+
+// severity - Returns severity of near-miss. Second returned value is false if measures are not below thresholds
+func (s *SafetyAnalyzer) severity(measures SafetyMeasures) (Severity, bool) {
+	ratio := math.Inf(1)
+	if s.TTCThreshold > 0 && measures.HasTTC && measures.TTC < s.TTCThreshold {
+		ratio = measures.TTC.Seconds() / s.TTCThreshold.Seconds()
+	}
+	if s.PETThreshold > 0 && measures.HasPET && measures.PET < s.PETThreshold {
+		ratio = math.Min(ratio, measures.PET.Seconds()/s.PETThreshold.Seconds())
+	}
+	switch {
+	case math.IsInf(ratio, 1):
+		return SeverityLow, false
+	case ratio < 1.0/3.0:
+		return SeverityHigh, true
+	case ratio < 2.0/3.0:
+		return SeverityMedium, true
+	default:
+		return SeverityLow, true
+	}
+}
+
+// postEncroachmentTime - Returns time passed between blob "first" leaving area and blob "second" entering it
+// Area is the last bounding box of "first" which overlaps current bounding box of "second". Second returned value is false when "first" has not occupied that area before or occupies it still.
+// Time of entering is the first frame of the latest run of frames where bounding box of "second" overlaps the area (see Blobie.GetRectTrack())
+func postEncroachmentTime(first, second Blobie) (time.Duration, bool) {
+	current := second.GetCurrentRect()
+	rects := first.GetRectTrack()
+	timestamps := first.GetTimestamps()
+	secondRects := second.GetRectTrack()
+	secondTimestamps := second.GetTimestamps()
+	if len(rects) < 2 || len(timestamps) != len(rects) || len(secondRects) == 0 || len(secondTimestamps) != len(secondRects) || rects[len(rects)-1].Overlaps(current) {
+		return 0, false
+	}
+	for i := len(rects) - 2; i >= 0; i-- {
+		if !rects[i].Overlaps(current) {
+			continue
+		}
+		entered := len(secondRects) - 1
+		for entered > 0 && secondRects[entered-1].Overlaps(rects[i]) {
+			entered--
+		}
+		pet := secondTimestamps[entered].Sub(timestamps[i])
+		return pet, pet >= 0
+	}
+	return 0, false
+}
+
+// trackVelocity - Returns velocity of blob (pixels per second) evaluated over last points of its track
+// Third returned value is false when there are not enough points with increasing timestamps
+func trackVelocity(b Blobie, window int) (float64, float64, bool) {
+	track := b.GetTrack()
+	timestamps := b.GetTimestamps()
+	if window < 2 {
+		window = 2
+	}
+	if window > len(track) {
+		window = len(track)
+	}
+	if window < 2 || len(timestamps) != len(track) {
+		return 0, 0, false
+	}
+	from, to := track[len(track)-window], track[len(track)-1]
+	seconds, ok := timeDeltaSeconds(timestamps[len(timestamps)-window], timestamps[len(timestamps)-1])
+	if !ok {
+		return 0, 0, false
+	}
+	return float64(to.X-from.X) / seconds, float64(to.Y-from.Y) / seconds, true
+}
+
+// overlapInterval - Returns time interval when |d + v*t| <= s. Interval is empty (from > to) when there is no such time
+func overlapInterval(d, v, s float64) (float64, float64) {
+	if v == 0 {
+		if math.Abs(d) <= s {
+			return math.Inf(-1), math.Inf(1)
+		}
+		return math.Inf(1), math.Inf(-1)
+	}
+	t1, t2 := (-s-d)/v, (s-d)/v
+	return math.Min(t1, t2), math.Max(t1, t2)
+}
+
+// rectCenter - Returns center of rectangle with sub-pixel precision
+func rectCenter(rect image.Rectangle) (float64, float64) {
+	return float64(rect.Min.X+rect.Max.X) / 2, float64(rect.Min.Y+rect.Max.Y) / 2
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
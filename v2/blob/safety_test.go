@@ -0,0 +1,136 @@
+package blob
+
+import (
+	"image"
+	"math"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// newMovingBlob - Creates blob moving along given centers (one center per 100 milliseconds)
+func newMovingBlob(startTime time.Time, className string, size int, centers []image.Point) Blobie {
+	options := BlobOptions{ClassID: 1, ClassName: className, MaxPointsInTrack: 10, Time: startTime}
+	rect := func(c image.Point) image.Rectangle {
+		return image.Rect(c.X-size/2, c.Y-size/2, c.X+size/2, c.Y+size/2)
+	}
+	b := NewSimpleBlobie(rect(centers[0]), &options)
+	b.SetID(uuid.NewV4())
+	for i := 1; i < len(centers); i++ {
+		options.Time = startTime.Add(time.Duration(i) * 100 * time.Millisecond)
+		b.Update(NewSimpleBlobie(rect(centers[i]), &options))
+	}
+	return b
+}
+
+func TestSafetyAnalyzerTTC(t *testing.T) {
+	analyzer, err := NewSafetyAnalyzer(1500*time.Millisecond, 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Head-on approach: 100 pixels per second each
+	a := newMovingBlob(startTime, "car", 40, []image.Point{{-20, 100}, {-10, 100}, {0, 100}, {10, 100}, {20, 100}})
+	b := newMovingBlob(startTime, "bicycle", 40, []image.Point{{360, 100}, {350, 100}, {340, 100}, {330, 100}, {320, 100}})
+	objects := map[uuid.UUID]Blobie{a.GetID(): a, b.GetID(): b}
+
+	measures, ok := analyzer.Measure(a, b)
+	if !ok {
+		t.Error("Measures should be evaluated")
+		return
+	}
+	// Gap between boxes is 260 pixels, closing speed is 200 pixels per second
+	if !measures.HasTTC || math.Abs(measures.TTC.Seconds()-1.3) > 1e-6 || measures.MinDistance != 0 {
+		t.Errorf("TTC should be %f s with zero minimum distance, but got %+v", 1.3, measures)
+	}
+	events := analyzer.Update(objects, startTime)
+	if len(events) != 1 || events[0].Severity != SeverityLow {
+		t.Errorf("Single near-miss with '%s' severity should be reported, but got %+v", SeverityLow, events)
+		return
+	}
+	classes := map[string]bool{events[0].ClassA: true, events[0].ClassB: true}
+	if !classes["car"] || !classes["bicycle"] {
+		t.Errorf("Near-miss should be between 'car' and 'bicycle', but got '%s' and '%s'", events[0].ClassA, events[0].ClassB)
+	}
+	if events = analyzer.Update(objects, startTime); len(events) != 0 {
+		t.Errorf("Near-miss should be reported once per episode, but got %+v", events)
+	}
+
+	// Parallel movement: no collision, but minimum distance is known
+	c := newMovingBlob(startTime, "car", 40, []image.Point{{-20, 200}, {-10, 200}, {0, 200}, {10, 200}, {20, 200}})
+	measures, _ = analyzer.Measure(a, c)
+	if measures.HasTTC || math.Abs(measures.MinDistance-60) > 1e-6 {
+		t.Errorf("There should be no TTC and minimum distance should be %f, but got %+v", 60.0, measures)
+	}
+}
+
+func TestSafetyAnalyzerPET(t *testing.T) {
+	analyzer, err := NewSafetyAnalyzer(0, time.Second)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Car passes the conflict area around (100, 100) and pedestrian enters it 400 milliseconds later
+	car := newMovingBlob(startTime, "car", 20, []image.Point{{100, 100}, {150, 100}, {200, 100}, {250, 100}, {300, 100}})
+	pedestrian := newMovingBlob(startTime, "person", 20, []image.Point{{100, 40}, {100, 55}, {100, 65}, {100, 75}, {100, 100}})
+	measures, ok := analyzer.Measure(car, pedestrian)
+	if !ok {
+		t.Error("Measures should be evaluated")
+		return
+	}
+	if measures.HasTTC || !measures.HasPET || measures.PET != 400*time.Millisecond {
+		t.Errorf("PET should be %s without TTC, but got %+v", 400*time.Millisecond, measures)
+	}
+	events := analyzer.Update(map[uuid.UUID]Blobie{car.GetID(): car, pedestrian.GetID(): pedestrian}, startTime)
+	if len(events) != 1 || events[0].Severity != SeverityMedium {
+		t.Errorf("Single near-miss with '%s' severity should be reported, but got %+v", SeverityMedium, events)
+	}
+}
+
+func TestSafetyAnalyzerPETStay(t *testing.T) {
+	analyzer, err := NewSafetyAnalyzer(0, time.Second)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Pedestrian enters the conflict area 200 milliseconds after the car has left it and stays there: PET must not grow while pedestrian is waiting
+	car := newMovingBlob(startTime, "car", 20, []image.Point{{100, 100}, {150, 100}, {200, 100}, {250, 100}, {300, 100}, {350, 100}})
+	pedestrian := newMovingBlob(startTime, "person", 20, []image.Point{{100, 60}, {100, 75}, {100, 100}, {100, 100}, {100, 102}, {100, 100}})
+	measures, ok := analyzer.Measure(car, pedestrian)
+	if !ok || !measures.HasPET || measures.PET != 200*time.Millisecond {
+		t.Errorf("PET should be %s, but got %+v", 200*time.Millisecond, measures)
+	}
+}
+
+func TestSafetyAnalyzerOverlapping(t *testing.T) {
+	analyzer, err := NewSafetyAnalyzer(1500*time.Millisecond, time.Second)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Two parked cars which bounding boxes overlap (40 pixels wide, 30 pixels between centers)
+	a := newMovingBlob(startTime, "car", 40, []image.Point{{100, 100}, {100, 100}, {100, 100}, {100, 100}, {100, 100}})
+	b := newMovingBlob(startTime, "car", 40, []image.Point{{130, 100}, {130, 100}, {130, 100}, {130, 100}, {130, 100}})
+	measures, ok := analyzer.Measure(a, b)
+	if !ok || measures.HasTTC || measures.HasPET {
+		t.Errorf("Parked cars should have neither TTC nor PET, but got %+v", measures)
+	}
+	if events := analyzer.Update(map[uuid.UUID]Blobie{a.GetID(): a, b.GetID(): b}, startTime); len(events) != 0 {
+		t.Errorf("No near-miss should be reported for parked cars, but got %+v", events)
+	}
+
+	// Overlapping boxes: diverging car has no TTC, approaching one has zero TTC
+	diverging := newMovingBlob(startTime, "car", 40, []image.Point{{110, 100}, {115, 100}, {120, 100}, {125, 100}, {130, 100}})
+	if measures, _ = analyzer.Measure(a, diverging); measures.HasTTC {
+		t.Errorf("Diverging car should have no TTC, but got %+v", measures)
+	}
+	approaching := newMovingBlob(startTime, "car", 40, []image.Point{{150, 100}, {145, 100}, {140, 100}, {135, 100}, {130, 100}})
+	if measures, _ = analyzer.Measure(a, approaching); !measures.HasTTC || measures.TTC != 0 {
+		t.Errorf("Approaching car should have zero TTC, but got %+v", measures)
+	}
+}
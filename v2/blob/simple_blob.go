@@ -19,6 +19,7 @@ type SimpleBlobie struct {
 	Diagonal              float64
 	AspectRatio           float64
 	Track                 []image.Point
+	RectTrack             []image.Rectangle
 	TrackTime             []time.Time
 	maxPointsInTrack      int
 	isExists              bool
@@ -26,13 +27,19 @@ type SimpleBlobie struct {
 	noMatchTimes          int
 	PredictedNextPosition image.Point
 
+	trackLifecycle
+
 	classID          int
 	className        string
+	classVotes       classVoter
+	speedOptions     *SpeedOptions
 	customProperties map[string]interface{}
 
 	// For array tracker
 	drawingOptions *DrawOptions
 	crossedLine    bool
+	// lineSide - Last strict side of the line checked by CheckObliqueLineCrossing* methods
+	lineSide int
 }
 
 // NewSimpleBlobie - Constructor for SimpleBlobie (default values)
@@ -47,9 +54,11 @@ func NewSimpleBlobie(rect image.Rectangle, options *BlobOptions) Blobie {
 		Diagonal:            math.Sqrt(math.Pow(width, 2) + math.Pow(height, 2)),
 		AspectRatio:         width / height,
 		Track:               []image.Point{center},
+		RectTrack:           []image.Rectangle{rect},
 		isExists:            true,
 		isStillBeingTracked: true,
 		noMatchTimes:        0,
+		trackLifecycle:      newTrackLifecycle(),
 		crossedLine:         false,
 		customProperties:    make(map[string]interface{}),
 	}
@@ -58,11 +67,14 @@ func NewSimpleBlobie(rect image.Rectangle, options *BlobOptions) Blobie {
 		blobie.maxPointsInTrack = options.MaxPointsInTrack
 		blobie.classID = options.ClassID
 		blobie.className = options.ClassName
+		blobie.classVotes = newClassVoter(options.ClassID, options.ClassName, options.MaxPointsInTrack)
+		blobie.speedOptions = options.Speed
 	} else {
 		blobie.TrackTime = []time.Time{time.Now()}
 		blobie.maxPointsInTrack = 10
 		blobie.classID = -1
 		blobie.className = "No class"
+		blobie.classVotes = newClassVoter(-1, "No class", 10)
 	}
 	return &blobie
 }
@@ -83,13 +95,16 @@ func NewBlobieDefaults(rect image.Rectangle) *SimpleBlobie {
 		Diagonal:            math.Sqrt(math.Pow(width, 2) + math.Pow(height, 2)),
 		AspectRatio:         width / height,
 		Track:               []image.Point{center},
+		RectTrack:           []image.Rectangle{rect},
 		TrackTime:           []time.Time{time.Now()},
 		maxPointsInTrack:    10,
 		isExists:            true,
 		isStillBeingTracked: true,
 		noMatchTimes:        0,
+		trackLifecycle:      newTrackLifecycle(),
 		classID:             -1,
 		className:           "No class",
+		classVotes:          newClassVoter(-1, "No class", 10),
 		customProperties:    make(map[string]interface{}),
 		crossedLine:         false,
 	}
@@ -131,14 +146,20 @@ func (b *SimpleBlobie) Update(newb Blobie) error {
 	b.Area = newbCast.Area
 	b.Diagonal = newbCast.Diagonal
 	b.AspectRatio = newbCast.AspectRatio
+	b.classID, b.className = b.classVotes.vote(b.classID, newbCast.classID, newbCast.className)
 	b.isStillBeingTracked = true
 	b.isExists = true
+	b.noMatchTimes = 0
+	b.registerHit()
 	// Append new point to track
 	b.Track = append(b.Track, newbCast.Center)
+	b.RectTrack = append(b.RectTrack, b.CurrentRect)
 	b.TrackTime = append(b.TrackTime, newbCast.TrackTime[len(newbCast.TrackTime)-1])
 	// Restrict number of points in track (shift to the left)
 	if len(b.Track) > b.maxPointsInTrack {
 		b.Track = b.Track[1:]
+		b.RectTrack = b.RectTrack[1:]
+		b.TrackTime = b.TrackTime[1:]
 	}
 	return nil
 }
@@ -159,6 +180,10 @@ func (sb *SimpleBlobie) GetTrack() []image.Point {
 	return sb.Track
 }
 
+func (sb *SimpleBlobie) GetRectTrack() []image.Rectangle {
+	return sb.RectTrack
+}
+
 func (sb *SimpleBlobie) GetTimestamps() []time.Time {
 	return sb.TrackTime
 }
@@ -0,0 +1,208 @@
+package blob
+
+import (
+	"math"
+	"sort"
+
+	"github.com/LdDl/gocv-blob/v2/calibration"
+)
+
+// madToSigma - Scale factor which makes median absolute deviation consistent estimator of standard deviation (for normal distribution)
+const madToSigma = 1.4826
+
+// msToKmh - Factor for converting meters per second to kilometers per hour
+const msToKmh = 3.6
+
+// SpeedOptions - Options for speed estimation
+type SpeedOptions struct {
+	// Window - Number of last track points used for smoothed speed. Minimum is 2
+	Window int
+	// OutlierThreshold - Steps of track which speed deviates from median by more than OutlierThreshold robust standard deviations (based on median absolute deviation) are ignored for smoothed speed. Zero value disables outlier rejection
+	OutlierThreshold float64
+	// Anchor - Point of bounding box which speed is estimated for. Bottom-center is recommended with ground calibration, since that is the point touching the road
+	Anchor Anchor
+	// Calibration - Homography which maps image points to ground points in meters. If nil, then only speed in pixels per second is estimated
+	Calibration *calibration.Homography
+}
+
+// DefaultSpeedOptions - Returns default speed options
+//
+// Default values are:
+// Window = 5
+// OutlierThreshold = 3
+// Anchor = center of bounding box
+// Calibration = nil
+func DefaultSpeedOptions() *SpeedOptions {
+	return &SpeedOptions{
+		Window:           5,
+		OutlierThreshold: 3,
+	}
+}
+
+// Speed - Estimated speed of blob
+type Speed struct {
+	// PixelsPerSecond - Instantaneous speed (between two last points of track)
+	PixelsPerSecond float64
+	// SmoothedPixelsPerSecond - Average speed over window of track with outliers being rejected
+	SmoothedPixelsPerSecond float64
+	// Calibrated - True when speed in kilometers per hour has been estimated (ground calibration is attached)
+	Calibrated bool
+	// KmPerHour - Instantaneous speed in kilometers per hour
+	KmPerHour float64
+	// SmoothedKmPerHour - Average speed over window of track in kilometers per hour with outliers being rejected
+	SmoothedKmPerHour float64
+	// Steps - Number of track steps used for smoothed speed (after outliers rejection)
+	Steps int
+}
+
+// speedStep - Movement of blob between two consecutive points of track
+type speedStep struct {
+	seconds float64
+	pixels  float64
+	meters  float64
+	// calibrated - false when any of points could not be projected on the ground
+	calibrated bool
+}
+
+func (step speedStep) pixelsPerSecond() float64 {
+	return step.pixels / step.seconds
+}
+
+// estimateSpeed - Estimates speed of blob using its track and timestamps
+// Second returned value is false when there are no steps with increasing timestamps in the window
+func estimateSpeed(b Blobie, options *SpeedOptions) (Speed, bool) {
+	if options == nil {
+		options = DefaultSpeedOptions()
+	}
+	track := options.Anchor.Track(b)
+	timestamps := b.GetTimestamps()
+	if len(track) < 2 || len(timestamps) != len(track) {
+		return Speed{}, false
+	}
+	window := options.Window
+	if window < 2 {
+		window = 2
+	}
+	if window > len(track) {
+		window = len(track)
+	}
+	track = track[len(track)-window:]
+	timestamps = timestamps[len(timestamps)-window:]
+
+	steps := make([]speedStep, 0, window-1)
+	for i := 1; i < len(track); i++ {
+		seconds, ok := timeDeltaSeconds(timestamps[i-1], timestamps[i])
+		if !ok {
+			continue
+		}
+		step := speedStep{
+			seconds: seconds,
+			pixels:  math.Hypot(float64(track[i].X-track[i-1].X), float64(track[i].Y-track[i-1].Y)),
+		}
+		if options.Calibration != nil {
+			from, errFrom := options.Calibration.ProjectImagePoint(track[i-1])
+			to, errTo := options.Calibration.ProjectImagePoint(track[i])
+			if errFrom == nil && errTo == nil {
+				step.meters = calibration.Distance(from, to)
+				step.calibrated = true
+			}
+		}
+		steps = append(steps, step)
+	}
+	if len(steps) == 0 {
+		return Speed{}, false
+	}
+
+	last := steps[len(steps)-1]
+	speed := Speed{
+		PixelsPerSecond: last.pixelsPerSecond(),
+		Calibrated:      options.Calibration != nil && last.calibrated,
+	}
+	if speed.Calibrated {
+		speed.KmPerHour = last.meters / last.seconds * msToKmh
+	}
+
+	inliers := rejectSpeedOutliers(steps, options.OutlierThreshold)
+	seconds, pixels, meters, calibratedSeconds := 0.0, 0.0, 0.0, 0.0
+	for _, step := range inliers {
+		seconds += step.seconds
+		pixels += step.pixels
+		if step.calibrated {
+			meters += step.meters
+			calibratedSeconds += step.seconds
+		}
+	}
+	speed.SmoothedPixelsPerSecond = pixels / seconds
+	if speed.Calibrated && calibratedSeconds > 0 {
+		speed.SmoothedKmPerHour = meters / calibratedSeconds * msToKmh
+	}
+	speed.Steps = len(inliers)
+	return speed, true
+}
+
+// rejectSpeedOutliers - Returns steps which speed (pixels per second) is not further from median than threshold robust standard deviations
+func rejectSpeedOutliers(steps []speedStep, threshold float64) []speedStep {
+	if threshold <= 0 || len(steps) < 3 {
+		return steps
+	}
+	speeds := make([]float64, len(steps))
+	for i := range steps {
+		speeds[i] = steps[i].pixelsPerSecond()
+	}
+	med := median(speeds)
+	deviations := make([]float64, len(speeds))
+	for i := range speeds {
+		deviations[i] = math.Abs(speeds[i] - med)
+	}
+	limit := threshold * madToSigma * median(deviations)
+	inliers := make([]speedStep, 0, len(steps))
+	for i := range steps {
+		// Small tolerance is needed when most of steps have exactly the same speed (median absolute deviation is zero)
+		if deviations[i] <= limit+1e-9 {
+			inliers = append(inliers, steps[i])
+		}
+	}
+	return inliers
+}
+
+// median - Returns median of values. Values are not modified
+func median(values []float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// GetSpeed - Returns estimated speed of blob (see SpeedOptions). Second returned value is false when there is not enough track points with increasing timestamps [SimpleBlobie]
+func (b *SimpleBlobie) GetSpeed() (Speed, bool) {
+	return estimateSpeed(b, b.speedOptions)
+}
+
+// SetSpeedOptions - Sets options for speed estimation. If nil, then DefaultSpeedOptions() are used [SimpleBlobie]
+func (b *SimpleBlobie) SetSpeedOptions(options *SpeedOptions) {
+	b.speedOptions = options
+}
+
+// GetSpeed - Returns estimated speed of blob (see SpeedOptions). Second returned value is false when there is not enough track points with increasing timestamps [KalmanBlobie]
+func (b *KalmanBlobie) GetSpeed() (Speed, bool) {
+	return estimateSpeed(b, b.speedOptions)
+}
+
+// SetSpeedOptions - Sets options for speed estimation. If nil, then DefaultSpeedOptions() are used [KalmanBlobie]
+func (b *KalmanBlobie) SetSpeedOptions(options *SpeedOptions) {
+	b.speedOptions = options
+}
+
+// GetSpeed - Returns estimated speed of blob (see SpeedOptions). Second returned value is false when there is not enough track points with increasing timestamps [KalmanBBoxBlobie]
+func (b *KalmanBBoxBlobie) GetSpeed() (Speed, bool) {
+	return estimateSpeed(b, b.speedOptions)
+}
+
+// SetSpeedOptions - Sets options for speed estimation. If nil, then DefaultSpeedOptions() are used [KalmanBBoxBlobie]
+func (b *KalmanBBoxBlobie) SetSpeedOptions(options *SpeedOptions) {
+	b.speedOptions = options
+}
@@ -0,0 +1,88 @@
+package blob
+
+import (
+	"image"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/LdDl/gocv-blob/v2/calibration"
+)
+
+func TestGetSpeed(t *testing.T) {
+	// Scale: 10 pixels per meter
+	h, err := calibration.NewHomographyFromMatrix([9]float64{0.1, 0, 0, 0, 0.1, 0, 0, 0, 1})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	options := BlobOptions{
+		ClassID:          1,
+		ClassName:        "car",
+		MaxPointsInTrack: 10,
+		Time:             startTime,
+		Speed:            &SpeedOptions{Window: 7, OutlierThreshold: 3, Calibration: h},
+	}
+	b := NewSimpleBlobie(image.Rect(0, 0, 20, 20), &options)
+	if _, ok := b.GetSpeed(); ok {
+		t.Error("Speed should not be estimated for blob with single point in track")
+	}
+	// 10 pixels per 100 milliseconds with single detection jitter (jump by 50 pixels)
+	xs := []int{10, 20, 30, 40, 90, 100}
+	for i, x := range xs {
+		options.Time = startTime.Add(time.Duration(i+1) * 100 * time.Millisecond)
+		b.Update(NewSimpleBlobie(image.Rect(x, 0, x+20, 20), &options))
+	}
+	speed, ok := b.GetSpeed()
+	if !ok {
+		t.Error("Speed should be estimated")
+		return
+	}
+	if math.Abs(speed.PixelsPerSecond-100) > 1e-6 {
+		t.Errorf("Instantaneous speed should be %f px/s, but got %f", 100.0, speed.PixelsPerSecond)
+	}
+	if math.Abs(speed.SmoothedPixelsPerSecond-100) > 1e-6 || speed.Steps != 5 {
+		t.Errorf("Smoothed speed should be %f px/s over %d steps, but got %f over %d steps", 100.0, 5, speed.SmoothedPixelsPerSecond, speed.Steps)
+	}
+	if !speed.Calibrated || math.Abs(speed.SmoothedKmPerHour-36) > 1e-6 {
+		t.Errorf("Smoothed speed should be %f km/h, but got %f (calibrated: %t)", 36.0, speed.SmoothedKmPerHour, speed.Calibrated)
+	}
+
+	// Without outlier rejection the jump affects smoothed speed
+	b.SetSpeedOptions(&SpeedOptions{Window: 7})
+	speed, _ = b.GetSpeed()
+	if math.Abs(speed.SmoothedPixelsPerSecond-1000.0/6.0) > 1e-6 || speed.Calibrated {
+		t.Errorf("Smoothed speed should be %f px/s (not calibrated), but got %f (calibrated: %t)", 1000.0/6.0, speed.SmoothedPixelsPerSecond, speed.Calibrated)
+	}
+}
+
+func TestTrackTimeRestricted(t *testing.T) {
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 4, Time: startTime}
+	constructors := []func(rect image.Rectangle, options *BlobOptions) Blobie{
+		func(rect image.Rectangle, options *BlobOptions) Blobie { return NewSimpleBlobie(rect, options) },
+		func(rect image.Rectangle, options *BlobOptions) Blobie { return NewKalmanBlobie(rect, options) },
+		func(rect image.Rectangle, options *BlobOptions) Blobie { return NewKalmanBBoxBlobie(rect, options) },
+	}
+	for _, newBlob := range constructors {
+		options.Time = startTime
+		b := newBlob(image.Rect(0, 0, 20, 20), &options)
+		for i := 1; i < 10; i++ {
+			options.Time = startTime.Add(time.Duration(i) * 100 * time.Millisecond)
+			if err := b.Update(newBlob(image.Rect(i*10, 0, i*10+20, 20), &options)); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+		// Timestamps are restricted together with track, so i-th timestamp corresponds to i-th point
+		track, rects, timestamps := b.GetTrack(), b.GetRectTrack(), b.GetTimestamps()
+		if len(track) != 4 || len(rects) != 4 || len(timestamps) != 4 {
+			t.Errorf("Blob %T: track, rectangles and timestamps should keep %d items, but got %d, %d and %d", b, 4, len(track), len(rects), len(timestamps))
+			continue
+		}
+		if correctTime := startTime.Add(600 * time.Millisecond); !timestamps[0].Equal(correctTime) {
+			t.Errorf("Blob %T: first timestamp should be %s, but got %s", b, correctTime, timestamps[0])
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package blob
+
+import (
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// SpeedRecord - Speed of blob measured by SpeedTrap
+type SpeedRecord struct {
+	TrapID string
+	BlobID uuid.UUID
+	// FromLineID - Identifier of line which has been crossed first
+	FromLineID string
+	// ToLineID - Identifier of line which has been crossed second
+	ToLineID string
+	// EntryTime - Interpolated moment of crossing the first line
+	EntryTime time.Time
+	// ExitTime - Interpolated moment of crossing the second line
+	ExitTime time.Time
+	// Elapsed - Time passed between crossings
+	Elapsed time.Duration
+	// KmPerHour - Measured speed
+	KmPerHour float64
+	// Confidence - Value in [0; 1] which reflects timing uncertainty caused by frame rate: 1 - (half of frame interval on entry + half of frame interval on exit) / Elapsed
+	Confidence float64
+}
+
+// SpeedTrap - Pair of counting lines with known real-world distance between them
+//
+// Speed is evaluated as distance divided by time passed between crossings of lines (in any order).
+// Lines keep crossing state of blobs, so they should not be shared with other counters
+type SpeedTrap struct {
+	ID    string
+	LineA *CountingLine
+	LineB *CountingLine
+	// Distance - Real-world distance between lines in meters
+	Distance float64
+	// MaxElapsed - Crossing of the first line is forgotten when the second one is not crossed within MaxElapsed. Zero value means no limit
+	MaxElapsed time.Duration
+
+	entries map[uuid.UUID]LineCrossing
+}
+
+// NewSpeedTrap - Constructor for SpeedTrap
+func NewSpeedTrap(id string, lineA, lineB *CountingLine, distance float64) (*SpeedTrap, error) {
+	if lineA == nil || lineB == nil {
+		return nil, fmt.Errorf("both lines of speed trap '%s' must be provided", id)
+	}
+	if lineA == lineB || lineA.ID == lineB.ID {
+		return nil, fmt.Errorf("lines of speed trap '%s' must be different", id)
+	}
+	if distance <= 0 {
+		return nil, fmt.Errorf("distance between lines of speed trap '%s' must be positive, but got %f", id, distance)
+	}
+	return &SpeedTrap{
+		ID:       id,
+		LineA:    lineA,
+		LineB:    lineB,
+		Distance: distance,
+		entries:  make(map[uuid.UUID]LineCrossing),
+	}, nil
+}
+
+// Check - Checks blob against both lines of the trap. Speed record is returned when blob has crossed the second line
+func (trap *SpeedTrap) Check(b Blobie) (SpeedRecord, bool) {
+	crossings := []LineCrossing{}
+	if crossing, ok := trap.LineA.Check(b); ok {
+		crossings = append(crossings, crossing)
+	}
+	if crossing, ok := trap.LineB.Check(b); ok {
+		crossings = append(crossings, crossing)
+	}
+	// Both lines could be crossed on the same frame by fast blob
+	if len(crossings) == 2 && crossings[1].InterpolatedTime.Before(crossings[0].InterpolatedTime) {
+		crossings[0], crossings[1] = crossings[1], crossings[0]
+	}
+	id := b.GetID()
+	for _, crossing := range crossings {
+		entry, ok := trap.entries[id]
+		if ok && trap.MaxElapsed > 0 && crossing.InterpolatedTime.Sub(entry.InterpolatedTime) > trap.MaxElapsed {
+			ok = false
+		}
+		if !ok || entry.LineID == crossing.LineID {
+			trap.entries[id] = crossing
+			continue
+		}
+		delete(trap.entries, id)
+		record, valid := trap.newRecord(entry, crossing)
+		if valid {
+			return record, true
+		}
+	}
+	return SpeedRecord{}, false
+}
+
+// newRecord - Creates speed record from crossings of two lines. Second returned value is false if time has not passed between crossings
+func (trap *SpeedTrap) newRecord(entry, exit LineCrossing) (SpeedRecord, bool) {
+	elapsed := exit.InterpolatedTime.Sub(entry.InterpolatedTime)
+	if elapsed <= 0 {
+		return SpeedRecord{}, false
+	}
+	uncertainty := (entry.FrameInterval + exit.FrameInterval).Seconds() / 2
+	confidence := 1 - uncertainty/elapsed.Seconds()
+	if confidence < 0 {
+		confidence = 0
+	}
+	return SpeedRecord{
+		TrapID:     trap.ID,
+		BlobID:     exit.BlobID,
+		FromLineID: entry.LineID,
+		ToLineID:   exit.LineID,
+		EntryTime:  entry.InterpolatedTime,
+		ExitTime:   exit.InterpolatedTime,
+		Elapsed:    elapsed,
+		KmPerHour:  trap.Distance / elapsed.Seconds() * msToKmh,
+		Confidence: confidence,
+	}, true
+}
+
+// Forget - Removes state of blob from the trap and its lines (e.g. when blob has been deregistered)
+func (trap *SpeedTrap) Forget(id uuid.UUID) {
+	delete(trap.entries, id)
+	trap.LineA.Forget(id)
+	trap.LineB.Forget(id)
+}
@@ -0,0 +1,64 @@
+package blob
+
+import (
+	"image"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSpeedTrap(t *testing.T) {
+	lineA, err := NewCountingLine("A", image.Pt(0, 100), image.Pt(200, 100))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	lineB, err := NewCountingLine("B", image.Pt(0, 300), image.Pt(200, 300))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	trap, err := NewSpeedTrap("trap", lineA, lineB, 5)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := NewSpeedTrap("bad", lineA, lineA, 5); err == nil {
+		t.Error("Speed trap with the same lines should produce an error")
+	}
+
+	// 25 FPS, 30 pixels per frame
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10, Time: startTime}
+	b := NewSimpleBlobie(image.Rect(90, 5, 110, 25), &options)
+	records := []SpeedRecord{}
+	for i := 1; i < 15; i++ {
+		options.Time = startTime.Add(time.Duration(i) * 40 * time.Millisecond)
+		y := 15 + i*30
+		b.Update(NewSimpleBlobie(image.Rect(90, y-10, 110, y+10), &options))
+		if record, ok := trap.Check(b); ok {
+			records = append(records, record)
+		}
+	}
+	if len(records) != 1 {
+		t.Errorf("Number of speed records should be %d, but got %d", 1, len(records))
+		return
+	}
+	record := records[0]
+	// Line A is crossed at 80ms + 5/6 of frame, line B is crossed at 360ms + 1/2 of frame
+	correctElapsed := 380*time.Millisecond - (80*time.Millisecond + 40*time.Millisecond*5/6)
+	if math.Abs(record.Elapsed.Seconds()-correctElapsed.Seconds()) > 1e-6 {
+		t.Errorf("Elapsed time should be %s, but got %s", correctElapsed, record.Elapsed)
+	}
+	correctSpeed := 5 / correctElapsed.Seconds() * 3.6
+	if math.Abs(record.KmPerHour-correctSpeed) > 1e-3 {
+		t.Errorf("Speed should be %f km/h, but got %f", correctSpeed, record.KmPerHour)
+	}
+	correctConfidence := 1 - 0.04/correctElapsed.Seconds()
+	if math.Abs(record.Confidence-correctConfidence) > 1e-3 {
+		t.Errorf("Confidence should be %f, but got %f", correctConfidence, record.Confidence)
+	}
+	if record.FromLineID != "A" || record.ToLineID != "B" {
+		t.Errorf("Record should be from line '%s' to line '%s', but got from '%s' to '%s'", "A", "B", record.FromLineID, record.ToLineID)
+	}
+}
@@ -0,0 +1,184 @@
+package blob
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// StationaryEventType - Type of stationary event
+type StationaryEventType int
+
+const (
+	// StationaryStart - Blob has been staying within radius for longer than threshold
+	StationaryStart = StationaryEventType(iota)
+	// StationaryEnd - Stationary blob has moved out of radius, left the zone or is not tracked anymore
+	StationaryEnd
+)
+
+// String - Returns text representation of StationaryEventType
+func (eventType StationaryEventType) String() string {
+	switch eventType {
+	case StationaryStart:
+		return "start"
+	case StationaryEnd:
+		return "end"
+	default:
+		return "unknown"
+	}
+}
+
+// StationaryEvent - Event of blob starting or ending to stay at the same place
+type StationaryEvent struct {
+	DetectorID string
+	Type       StationaryEventType
+	BlobID     uuid.UUID
+	// Blob - Blob which event is related to. It is nil for StationaryEnd event when blob is not tracked anymore
+	Blob Blobie
+	// Time - Timestamp of frame on which event has happened
+	Time time.Time
+	// Duration - Time which blob has been staying within radius (based on blob's timestamps)
+	Duration time.Duration
+	// Origin - Centroid of blob's points within the last Threshold seconds at the moment when blob has become stationary
+	Origin image.Point
+}
+
+// StationaryDetector - Detector of stopped objects (e.g. vehicle in no-stopping area) and loitering (e.g. person lingering in restricted area)
+//
+// Blob is considered to be staying when every anchor point of its track within the last Threshold seconds is located within Radius from centroid of those points,
+// so single noisy detection does not define the place of stay. Blob becomes stationary when it has been staying for longer than Threshold.
+// Time of stay is measured by blob's timestamps (see Blobie.GetTimestamps()).
+// Stopped objects are usually detected with small radius and short threshold, while loitering - with radius of several body sizes and long threshold
+type StationaryDetector struct {
+	ID string
+	// Radius - Maximum displacement (in pixels) from centroid of the recent points
+	Radius float64
+	// Threshold - Minimum time of stay
+	Threshold time.Duration
+	// Polygon - If provided, then only blobs inside of the polygon are watched
+	Polygon Polygon
+	// Anchor - Point of blob's bounding box which is watched. Default is center of bounding box
+	Anchor Anchor
+
+	states map[uuid.UUID]*stationaryState
+}
+
+// stationaryState - State of blob's current stay
+type stationaryState struct {
+	origin     image.Point
+	since      time.Time
+	lastTime   time.Time
+	stationary bool
+}
+
+// NewStationaryDetector - Constructor for StationaryDetector
+func NewStationaryDetector(id string, radius float64, threshold time.Duration) (*StationaryDetector, error) {
+	if radius <= 0 {
+		return nil, fmt.Errorf("radius of detector '%s' must be positive, but got %f", id, radius)
+	}
+	if threshold <= 0 {
+		return nil, fmt.Errorf("threshold of detector '%s' must be positive, but got %s", id, threshold)
+	}
+	return &StationaryDetector{
+		ID:        id,
+		Radius:    radius,
+		Threshold: threshold,
+		states:    make(map[uuid.UUID]*stationaryState),
+	}, nil
+}
+
+// Update - Evaluates displacement of each blob and returns events happened on the frame
+//
+// objects - blobs currently being tracked (e.g. Blobies.Objects or Blobies.ObjectsInState(...)).
+// Stationary blobs which are not present in objects anymore produce StationaryEnd event.
+func (d *StationaryDetector) Update(objects map[uuid.UUID]Blobie, frameTime time.Time) []StationaryEvent {
+	events := []StationaryEvent{}
+	for id, b := range objects {
+		track := d.Anchor.Track(b)
+		timestamps := b.GetTimestamps()
+		if len(track) == 0 || len(timestamps) != len(track) {
+			continue
+		}
+		last, lastTime := track[len(track)-1], timestamps[len(timestamps)-1]
+		if len(d.Polygon) > 0 && !d.Polygon.Contains(last) {
+			events = d.finish(events, id, b, frameTime)
+			continue
+		}
+		from := len(track) - 1
+		for from > 0 && lastTime.Sub(timestamps[from-1]) <= d.Threshold {
+			from--
+		}
+		centroid, staying := d.recentCentroid(track[from:])
+		if !staying {
+			events = d.finish(events, id, b, frameTime)
+			continue
+		}
+		state, ok := d.states[id]
+		if !ok {
+			state = &stationaryState{since: timestamps[from]}
+			d.states[id] = state
+		}
+		state.lastTime = lastTime
+		if !state.stationary && state.lastTime.Sub(state.since) >= d.Threshold {
+			state.stationary = true
+			state.origin = centroid
+			events = append(events, d.newEvent(StationaryStart, id, b, frameTime, state))
+		}
+	}
+	for id := range d.states {
+		if _, ok := objects[id]; !ok {
+			events = d.finish(events, id, nil, frameTime)
+		}
+	}
+	return events
+}
+
+// recentCentroid - Returns centroid of points and checks if all of them are located within Radius from it
+func (d *StationaryDetector) recentCentroid(points []image.Point) (image.Point, bool) {
+	sumX, sumY := 0.0, 0.0
+	for _, pt := range points {
+		sumX += float64(pt.X)
+		sumY += float64(pt.Y)
+	}
+	cx, cy := sumX/float64(len(points)), sumY/float64(len(points))
+	for _, pt := range points {
+		if math.Hypot(float64(pt.X)-cx, float64(pt.Y)-cy) > d.Radius {
+			return image.Point{}, false
+		}
+	}
+	return image.Pt(int(math.Round(cx)), int(math.Round(cy))), true
+}
+
+// IsStationary - Checks if blob is stationary at the moment
+func (d *StationaryDetector) IsStationary(id uuid.UUID) bool {
+	state, ok := d.states[id]
+	return ok && state.stationary
+}
+
+// finish - Removes state of blob's stay and appends StationaryEnd event if blob has been stationary
+func (d *StationaryDetector) finish(events []StationaryEvent, id uuid.UUID, b Blobie, frameTime time.Time) []StationaryEvent {
+	state, ok := d.states[id]
+	if !ok {
+		return events
+	}
+	delete(d.states, id)
+	if !state.stationary {
+		return events
+	}
+	return append(events, d.newEvent(StationaryEnd, id, b, frameTime, state))
+}
+
+func (d *StationaryDetector) newEvent(eventType StationaryEventType, id uuid.UUID, b Blobie, frameTime time.Time, state *stationaryState) StationaryEvent {
+	return StationaryEvent{
+		DetectorID: d.ID,
+		Type:       eventType,
+		BlobID:     id,
+		Blob:       b,
+		Time:       frameTime,
+		Duration:   state.lastTime.Sub(state.since),
+		Origin:     state.origin,
+	}
+}
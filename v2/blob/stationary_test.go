@@ -0,0 +1,92 @@
+package blob
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestStationaryDetector(t *testing.T) {
+	detector, err := NewStationaryDetector("no_stopping", 5, 3*time.Second)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	detector.Polygon = Polygon{image.Pt(0, 0), image.Pt(300, 0), image.Pt(300, 100), image.Pt(0, 100)}
+
+	allblobies := NewBlobiesDefaults()
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10}
+	// Blob moves, stops with small jitter for 5 seconds and moves again
+	xs := []int{40, 60, 80, 100, 102, 99, 101, 100, 102, 120, 140}
+	events := []StationaryEvent{}
+	for i, x := range xs {
+		options.Time = startTime.Add(time.Duration(i) * time.Second)
+		allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(image.Rect(x-20, 30, x+20, 70), &options)})
+		events = append(events, detector.Update(allblobies.Objects, options.Time)...)
+		if i == 7 {
+			for id := range allblobies.Objects {
+				if !detector.IsStationary(id) {
+					t.Errorf("Blob should be stationary on frame %d", i)
+				}
+			}
+		}
+	}
+	if len(events) != 2 {
+		t.Errorf("Number of events should be %d, but got %d", 2, len(events))
+		return
+	}
+	if events[0].Type != StationaryStart || !events[0].Time.Equal(startTime.Add(6*time.Second)) || events[0].Origin != image.Pt(101, 50) {
+		t.Errorf("First event should be '%s' on %s at %v, but got %+v", StationaryStart, startTime.Add(6*time.Second), image.Pt(101, 50), events[0])
+	}
+	if events[1].Type != StationaryEnd || events[1].Duration != 5*time.Second {
+		t.Errorf("Second event should be '%s' with duration %s, but got %+v", StationaryEnd, 5*time.Second, events[1])
+	}
+
+	// Stationary blob which is not tracked anymore
+	b := NewSimpleBlobie(image.Rect(230, 30, 270, 70), &options)
+	b.SetID(uuid.NewV4())
+	objects := map[uuid.UUID]Blobie{b.GetID(): b}
+	detector.Update(objects, options.Time)
+	for i := 0; i < 5; i++ {
+		options.Time = options.Time.Add(time.Second)
+		b.Update(NewSimpleBlobie(b.GetCurrentRect(), &options))
+		detector.Update(objects, options.Time)
+	}
+	events = detector.Update(map[uuid.UUID]Blobie{}, options.Time)
+	if len(events) != 1 || events[0].Type != StationaryEnd || events[0].Blob != nil {
+		t.Errorf("End event should be emitted for stationary blob which is not tracked anymore, but got %+v", events)
+	}
+}
+
+func TestStationaryDetectorJitter(t *testing.T) {
+	detector, err := NewStationaryDetector("no_stopping", 5, 3*time.Second)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10, Time: startTime}
+	// Stopped vehicle with detection jitter: the first point of stay is 8 pixels away from the next one, but both are within radius from the actual position
+	b := NewSimpleBlobie(image.Rect(84, 30, 124, 70), &options)
+	b.SetID(uuid.NewV4())
+	objects := map[uuid.UUID]Blobie{b.GetID(): b}
+	events := detector.Update(objects, options.Time)
+	for i := 1; i < 6; i++ {
+		options.Time = startTime.Add(time.Duration(i) * time.Second)
+		x := 104
+		if i%2 == 1 {
+			x = 96
+		}
+		if err := b.Update(NewSimpleBlobie(image.Rect(x-20, 30, x+20, 70), &options)); err != nil {
+			t.Error(err)
+			return
+		}
+		events = append(events, detector.Update(objects, options.Time)...)
+	}
+	if len(events) != 1 || events[0].Type != StationaryStart || !events[0].Time.Equal(startTime.Add(3*time.Second)) || events[0].Origin != image.Pt(100, 50) {
+		t.Errorf("Single '%s' event on %s at %v should be emitted, but got %+v", StationaryStart, startTime.Add(3*time.Second), image.Pt(100, 50), events)
+	}
+}
@@ -0,0 +1,14 @@
+package blob
+
+import (
+	"time"
+)
+
+// timeBinStart - Returns start of time bin which timestamp belongs to
+// Zero time is returned when bin size is not positive (single bin) or timestamp is not set
+func timeBinStart(t time.Time, binSize time.Duration) time.Time {
+	if binSize <= 0 || t.IsZero() {
+		return time.Time{}
+	}
+	return t.Truncate(binSize)
+}
@@ -0,0 +1,46 @@
+package blob
+
+import (
+	"math"
+	"time"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// timeDeltaSeconds - Returns time passed between two timestamps in seconds
+// Second returned value is false when any of timestamps is not set or when timestamps are not increasing
+func timeDeltaSeconds(from, to time.Time) (float64, bool) {
+	if from.IsZero() || to.IsZero() || !to.After(from) {
+		return 0, false
+	}
+	return to.Sub(from).Seconds(), true
+}
+
+// whiteAccelerationNoise - Returns process noise covariance of constant velocity model for time step dt (piecewise white acceleration model)
+// State consists of n positions followed by n velocities. Each pair (position, velocity) gets Q = G * G^T * variance, where G = [dt^2/2, dt]^T
+func whiteAccelerationNoise(dt float64, variances []float64) *mat.Dense {
+	n := len(variances)
+	Q := mat.NewDense(2*n, 2*n, nil)
+	for i, variance := range variances {
+		Q.Set(i, i, variance*math.Pow(dt, 4)/4)
+		Q.Set(i, i+n, variance*math.Pow(dt, 3)/2)
+		Q.Set(i+n, i, variance*math.Pow(dt, 3)/2)
+		Q.Set(i+n, i+n, variance*dt*dt)
+	}
+	return Q
+}
+
+// accelerationVariances - Returns variances of acceleration for white acceleration model (see whiteAccelerationNoise)
+// They are chosen so that velocity noise of the model for nominal time step equals to velocity noise of nominal process noise matrix
+func accelerationVariances(nominalQ *mat.Dense, nominal float64) []float64 {
+	size, _ := nominalQ.Dims()
+	n := size / 2
+	variances := make([]float64, n)
+	for i := range variances {
+		variances[i] = nominalQ.At(i+n, i+n)
+		if nominal > 0 {
+			variances[i] /= nominal * nominal
+		}
+	}
+	return variances
+}
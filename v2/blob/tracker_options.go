@@ -0,0 +1,133 @@
+package blob
+
+import (
+	"fmt"
+)
+
+// TrackerOption - Option for configuring Blobies (see NewBlobies)
+type TrackerOption func(bt *Blobies) error
+
+// NewBlobies - Constructor for Blobies (custom values)
+//
+// Values which are not provided via options are the same as in NewBlobiesDefaults
+func NewBlobies(opts ...TrackerOption) (*Blobies, error) {
+	bt := NewBlobiesDefaults()
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if err := opt(bt); err != nil {
+			return nil, err
+		}
+	}
+	return bt, nil
+}
+
+// WithMaxNoMatch - Sets max number of consecutive frames without matched detection before blob is deregistered (max age)
+func WithMaxNoMatch(maxNoMatch int) TrackerOption {
+	return func(bt *Blobies) error {
+		if maxNoMatch < 1 {
+			return fmt.Errorf("max no match value must be positive, but got %d", maxNoMatch)
+		}
+		bt.maxNoMatch = maxNoMatch
+		return nil
+	}
+}
+
+// WithMinThresholdDistance - Sets distance (in pixels) below which detection is always matched to existing blob when cost metric is CostDistance
+func WithMinThresholdDistance(distance float64) TrackerOption {
+	return func(bt *Blobies) error {
+		if distance < 0 {
+			return fmt.Errorf("min threshold distance must be non-negative, but got %f", distance)
+		}
+		bt.minThresholdDistance = distance
+		return nil
+	}
+}
+
+// WithDiagonalGateFactor - Sets fraction of detection's diagonal below which detection is matched to existing blob when cost metric is CostDistance
+func WithDiagonalGateFactor(factor float64) TrackerOption {
+	return func(bt *Blobies) error {
+		if factor < 0 {
+			return fmt.Errorf("diagonal gate factor must be non-negative, but got %f", factor)
+		}
+		bt.diagonalGateFactor = factor
+		return nil
+	}
+}
+
+// WithPredictionWindow - Sets number of last track points which are used for predicting next position of blob
+func WithPredictionWindow(window int) TrackerOption {
+	return func(bt *Blobies) error {
+		if window < 1 {
+			return fmt.Errorf("prediction window must be positive, but got %d", window)
+		}
+		bt.predictionWindow = window
+		return nil
+	}
+}
+
+// WithConfirmation - Sets number of detections (minHits) which are needed within first frames (window) for tentative blob to become confirmed
+//
+// Default values (minHits = 1, window = 1) mean that every blob is confirmed right after registration
+func WithConfirmation(minHits, window int) TrackerOption {
+	return func(bt *Blobies) error {
+		if minHits < 1 {
+			return fmt.Errorf("min hits must be positive, but got %d", minHits)
+		}
+		if window < minHits {
+			return fmt.Errorf("confirmation window must be not less than min hits (%d), but got %d", minHits, window)
+		}
+		bt.minHits = minHits
+		bt.confirmationWindow = window
+		return nil
+	}
+}
+
+// WithMatchingStrategy - Sets strategy of associating new detections with existing blobs
+func WithMatchingStrategy(strategy MatchingStrategy) TrackerOption {
+	return func(bt *Blobies) error {
+		switch strategy {
+		case MatchingGreedy, MatchingHungarian:
+			bt.matchingStrategy = strategy
+			return nil
+		default:
+			return fmt.Errorf("unknown matching strategy: %d", strategy)
+		}
+	}
+}
+
+// WithCostMetric - Sets metric of associating new detections with existing blobs and gating threshold for IoU-family metrics (see SetMinOverlap)
+func WithCostMetric(metric CostMetric, minOverlap float64) TrackerOption {
+	return func(bt *Blobies) error {
+		switch metric {
+		case CostDistance, CostIoU, CostGIoU, CostDIoU, CostCIoU:
+		default:
+			return fmt.Errorf("unknown cost metric: %d", metric)
+		}
+		if minOverlap < -1 || minOverlap > 1 {
+			return fmt.Errorf("min overlap must be in [-1; 1], but got %f", minOverlap)
+		}
+		bt.costMetric = metric
+		bt.minOverlap = minOverlap
+		return nil
+	}
+}
+
+// WithClassGating - Sets mode of class-aware association (see SetClassGating)
+func WithClassGating(mode ClassGating, compatibility *ClassCompatibility) TrackerOption {
+	return func(bt *Blobies) error {
+		switch mode {
+		case ClassGatingNone, ClassGatingStrict:
+		case ClassGatingMatrix:
+			if compatibility == nil {
+				return fmt.Errorf("class compatibility must be provided for ClassGatingMatrix mode")
+			}
+		default:
+			return fmt.Errorf("unknown class gating mode: %d", mode)
+		}
+		bt.classGating = mode
+		bt.classCompatibility = compatibility
+		return nil
+	}
+}
@@ -0,0 +1,54 @@
+package blob
+
+import (
+	"image"
+	"testing"
+)
+
+func TestNewBlobies(t *testing.T) {
+	allblobies, err := NewBlobies(
+		WithMaxNoMatch(2),
+		WithMinThresholdDistance(10),
+		WithDiagonalGateFactor(0.25),
+		WithPredictionWindow(3),
+		WithMatchingStrategy(MatchingHungarian),
+		WithCostMetric(CostDistance, 0.5),
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if allblobies.maxNoMatch != 2 || allblobies.minThresholdDistance != 10 || allblobies.diagonalGateFactor != 0.25 || allblobies.predictionWindow != 3 {
+		t.Errorf("Options have not been applied: %+v", allblobies)
+	}
+	if allblobies.matchingStrategy != MatchingHungarian || allblobies.minOverlap != 0.5 {
+		t.Errorf("Options have not been applied: %+v", allblobies)
+	}
+
+	// Blob should be deregistered after maxNoMatch frames without detections
+	allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(image.Rect(0, 0, 10, 10), nil)})
+	allblobies.MatchToExisting([]Blobie{})
+	if len(allblobies.Objects) != 1 {
+		t.Errorf("Total number of blobs should be %d, but got %d", 1, len(allblobies.Objects))
+	}
+	allblobies.MatchToExisting([]Blobie{})
+	if len(allblobies.Objects) != 0 {
+		t.Errorf("Total number of blobs should be %d, but got %d", 0, len(allblobies.Objects))
+	}
+
+	badOptions := []TrackerOption{
+		WithMaxNoMatch(0),
+		WithMinThresholdDistance(-1),
+		WithDiagonalGateFactor(-0.5),
+		WithPredictionWindow(0),
+		WithMatchingStrategy(MatchingStrategy(100)),
+		WithCostMetric(CostIoU, 2),
+		WithCostMetric(CostMetric(100), 0.3),
+		WithClassGating(ClassGatingMatrix, nil),
+	}
+	for i, opt := range badOptions {
+		if _, err := NewBlobies(opt); err == nil {
+			t.Errorf("Option #%d should produce an error", i)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package blob
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// WrongWayEvent - Event of blob moving against allowed direction of the lane
+type WrongWayEvent struct {
+	LaneID string
+	BlobID uuid.UUID
+	Blob   Blobie
+	// Time - Timestamp of frame on which violation has been confirmed
+	Time time.Time
+	// Deviation - Angle (in degrees) between blob's heading and allowed heading of the lane
+	Deviation float64
+	// Distance - Distance (in pixels) which blob has moved in wrong direction
+	Distance float64
+	// Duration - Time which blob has been moving in wrong direction
+	Duration time.Duration
+}
+
+// Lane - Region (polygon) with allowed direction of movement
+//
+// Blob's heading is evaluated from its recent track points. Movement is considered to be wrong-way when heading deviates from allowed one by more than Tolerance.
+// Violation is raised once per episode of wrong-way movement: when blob has moved in wrong direction for at least MinDistance or for at least MinDuration (if both are zero, then violation is raised immediately)
+type Lane struct {
+	ID      string
+	Polygon Polygon
+	// Heading - Allowed direction of movement in image coordinates (e.g. image.Pt(0, 1) for movement to the bottom of image)
+	Heading image.Point
+	// Tolerance - Maximum allowed deviation (in degrees) from Heading
+	Tolerance float64
+	// MinDistance - Distance (in pixels) of wrong-way movement which raises violation. Zero value disables this condition
+	MinDistance float64
+	// MinDuration - Duration of wrong-way movement which raises violation. Zero value disables this condition
+	MinDuration time.Duration
+	// HeadingWindow - Number of last track points which heading is evaluated for
+	HeadingWindow int
+	// MinDisplacement - Minimum displacement (in pixels) over HeadingWindow which is needed to evaluate heading. Prevents jitter of standing blobs from being treated as movement
+	MinDisplacement float64
+	// Anchor - Point of blob's bounding box which is tested against the lane. Default is center of bounding box
+	Anchor Anchor
+
+	states map[uuid.UUID]*wrongWayState
+}
+
+// wrongWayState - State of blob which moves in wrong direction
+type wrongWayState struct {
+	startedAt time.Time
+	lastPoint image.Point
+	distance  float64
+	reported  bool
+}
+
+// NewLane - Constructor for Lane
+//
+// Default values are:
+// HeadingWindow = 5
+// MinDisplacement = 5 (pixels)
+// MinDistance = 0, MinDuration = 0 (violation is raised as soon as wrong-way heading is detected)
+func NewLane(id string, polygon Polygon, heading image.Point, tolerance float64) (*Lane, error) {
+	if len(polygon) < 3 {
+		return nil, fmt.Errorf("polygon of lane '%s' must have at least 3 vertices, but got %d", id, len(polygon))
+	}
+	if heading == image.ZP {
+		return nil, fmt.Errorf("heading of lane '%s' must be non-zero vector", id)
+	}
+	if tolerance < 0 || tolerance > 180 {
+		return nil, fmt.Errorf("tolerance of lane '%s' must be in [0; 180] degrees, but got %f", id, tolerance)
+	}
+	return &Lane{
+		ID:              id,
+		Polygon:         polygon,
+		Heading:         heading,
+		Tolerance:       tolerance,
+		HeadingWindow:   5,
+		MinDisplacement: 5,
+		states:          make(map[uuid.UUID]*wrongWayState),
+	}, nil
+}
+
+// Update - Evaluates heading of each blob inside of the lane and returns violations happened on the frame
+//
+// objects - blobs currently being tracked (e.g. Blobies.Objects or Blobies.ObjectsInState(...))
+func (lane *Lane) Update(objects map[uuid.UUID]Blobie, frameTime time.Time) []WrongWayEvent {
+	events := []WrongWayEvent{}
+	for id, b := range objects {
+		track := lane.Anchor.Track(b)
+		if len(track) == 0 || !lane.Polygon.Contains(track[len(track)-1]) {
+			delete(lane.states, id)
+			continue
+		}
+		deviation, ok := lane.deviation(track)
+		if !ok {
+			// Heading is unknown (e.g. blob is standing): keep the state as is
+			continue
+		}
+		last := track[len(track)-1]
+		if deviation <= lane.Tolerance {
+			delete(lane.states, id)
+			continue
+		}
+		state, ok := lane.states[id]
+		if !ok {
+			state = &wrongWayState{startedAt: frameTime, lastPoint: last}
+			lane.states[id] = state
+		}
+		state.distance += math.Hypot(float64(last.X-state.lastPoint.X), float64(last.Y-state.lastPoint.Y))
+		state.lastPoint = last
+		duration := frameTime.Sub(state.startedAt)
+		if state.reported || !lane.isViolation(state.distance, duration) {
+			continue
+		}
+		state.reported = true
+		events = append(events, WrongWayEvent{
+			LaneID:    lane.ID,
+			BlobID:    id,
+			Blob:      b,
+			Time:      frameTime,
+			Deviation: deviation,
+			Distance:  state.distance,
+			Duration:  duration,
+		})
+	}
+	for id := range lane.states {
+		if _, ok := objects[id]; !ok {
+			delete(lane.states, id)
+		}
+	}
+	return events
+}
+
+// isViolation - Checks if wrong-way movement has lasted long enough to raise violation
+func (lane *Lane) isViolation(distance float64, duration time.Duration) bool {
+	if lane.MinDistance <= 0 && lane.MinDuration <= 0 {
+		return true
+	}
+	return (lane.MinDistance > 0 && distance >= lane.MinDistance) || (lane.MinDuration > 0 && duration >= lane.MinDuration)
+}
+
+// deviation - Returns angle (in degrees) between heading of track and allowed heading of the lane
+// Second returned value is false when track is too short or displacement is too small to evaluate heading
+func (lane *Lane) deviation(track []image.Point) (float64, bool) {
+	window := lane.HeadingWindow
+	if window < 2 {
+		window = 2
+	}
+	if window > len(track) {
+		window = len(track)
+	}
+	if window < 2 {
+		return 0, false
+	}
+	from, to := track[len(track)-window], track[len(track)-1]
+	dx, dy := float64(to.X-from.X), float64(to.Y-from.Y)
+	displacement := math.Hypot(dx, dy)
+	if displacement == 0 || displacement < lane.MinDisplacement {
+		return 0, false
+	}
+	hx, hy := float64(lane.Heading.X), float64(lane.Heading.Y)
+	cos := (dx*hx + dy*hy) / (displacement * math.Hypot(hx, hy))
+	cos = math.Max(-1, math.Min(1, cos))
+	return math.Acos(cos) * 180 / math.Pi, true
+}
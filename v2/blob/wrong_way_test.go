@@ -0,0 +1,43 @@
+package blob
+
+import (
+	"image"
+	"testing"
+	"time"
+)
+
+func TestLaneWrongWay(t *testing.T) {
+	lane, err := NewLane("southbound", Polygon{image.Pt(0, 0), image.Pt(100, 0), image.Pt(100, 400), image.Pt(0, 400)}, image.Pt(0, 1), 60)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	lane.MinDistance = 50
+	if _, err := NewLane("bad", lane.Polygon, image.ZP, 60); err == nil {
+		t.Error("Lane with zero heading should produce an error")
+	}
+
+	allblobies := NewBlobiesDefaults()
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10}
+	events := []WrongWayEvent{}
+	for i := 0; i < 15; i++ {
+		options.Time = startTime.Add(time.Duration(i) * 100 * time.Millisecond)
+		// First blob moves down (allowed direction), second one moves up with slight drift to the right
+		correct := NewSimpleBlobie(image.Rect(10, 10+i*10, 30, 30+i*10), &options)
+		wrong := NewSimpleBlobie(image.Rect(60+i, 360-i*10, 80+i, 380-i*10), &options)
+		allblobies.MatchToExisting([]Blobie{correct, wrong})
+		events = append(events, lane.Update(allblobies.Objects, options.Time)...)
+	}
+	if len(events) != 1 {
+		t.Errorf("Number of wrong-way events should be %d, but got %d", 1, len(events))
+		return
+	}
+	event := events[0]
+	if center := event.Blob.GetCenter(); center.X < 50 {
+		t.Errorf("Wrong-way event should be raised for blob moving up, but got blob at %v", center)
+	}
+	if event.Distance < lane.MinDistance || event.Deviation <= lane.Tolerance {
+		t.Errorf("Violation should be raised after %f pixels with deviation more than %f degrees, but got %f pixels and %f degrees", lane.MinDistance, lane.Tolerance, event.Distance, event.Deviation)
+	}
+}
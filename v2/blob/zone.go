@@ -0,0 +1,172 @@
+package blob
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Polygon - Closed polygon defined by its vertices (last vertex is connected to the first one)
+type Polygon []image.Point
+
+// Contains - Checks if point lies inside of polygon (points on the border are considered to be inside)
+func (polygon Polygon) Contains(pt image.Point) bool {
+	n := len(polygon)
+	if n < 3 {
+		return false
+	}
+	inside := false
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		a, b := polygon[j], polygon[i]
+		// Point on the edge
+		if getOrientation(a.X, a.Y, b.X, b.Y, pt.X, pt.Y) == Collinear && isOnSegment(a.X, a.Y, pt.X, pt.Y, b.X, b.Y) {
+			return true
+		}
+		// Ray casting to the right
+		if (b.Y > pt.Y) != (a.Y > pt.Y) {
+			intersectX := float64(a.X-b.X)*float64(pt.Y-b.Y)/float64(a.Y-b.Y) + float64(b.X)
+			if float64(pt.X) < intersectX {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// ZoneEventType - Type of zone event
+type ZoneEventType int
+
+const (
+	// ZoneEnter - Blob has entered the zone
+	ZoneEnter = ZoneEventType(iota)
+	// ZoneExit - Blob has left the zone (or blob has been deregistered while being in the zone)
+	ZoneExit
+	// ZoneDwell - Blob has been staying in the zone for longer than dwell threshold
+	ZoneDwell
+)
+
+// String - Returns text representation of ZoneEventType
+func (eventType ZoneEventType) String() string {
+	switch eventType {
+	case ZoneEnter:
+		return "enter"
+	case ZoneExit:
+		return "exit"
+	case ZoneDwell:
+		return "dwell"
+	default:
+		return "unknown"
+	}
+}
+
+// ZoneEvent - Event of blob interacting with the zone
+type ZoneEvent struct {
+	ZoneID string
+	Type   ZoneEventType
+	BlobID uuid.UUID
+	// Blob - Blob which event is related to. It is nil for ZoneExit event when blob is not tracked anymore
+	Blob Blobie
+	// Time - Timestamp of frame on which event has happened
+	Time time.Time
+	// Dwell - Time which blob has spent in the zone (for ZoneDwell and ZoneExit events)
+	Dwell time.Duration
+}
+
+// Zone - Region of interest defined by arbitrary polygon
+type Zone struct {
+	ID      string
+	Polygon Polygon
+	// DwellThreshold - Time after which ZoneDwell event is emitted for blob staying in the zone. Zero value disables ZoneDwell events
+	DwellThreshold time.Duration
+	// Anchor - Point of blob's bounding box which is tested against the polygon. Default is center of bounding box
+	Anchor Anchor
+
+	members map[uuid.UUID]*zoneMember
+}
+
+// zoneMember - Information about blob which is inside of the zone
+type zoneMember struct {
+	enteredAt     time.Time
+	dwellReported bool
+}
+
+// NewZone - Constructor for Zone
+func NewZone(id string, polygon Polygon, dwellThreshold time.Duration) (*Zone, error) {
+	if len(polygon) < 3 {
+		return nil, fmt.Errorf("polygon of zone '%s' must have at least 3 vertices, but got %d", id, len(polygon))
+	}
+	if dwellThreshold < 0 {
+		return nil, fmt.Errorf("dwell threshold of zone '%s' must be non-negative, but got %s", id, dwellThreshold)
+	}
+	return &Zone{
+		ID:             id,
+		Polygon:        polygon,
+		DwellThreshold: dwellThreshold,
+		members:        make(map[uuid.UUID]*zoneMember),
+	}, nil
+}
+
+// Update - Evaluates position of each blob against the zone and returns events happened on the frame
+//
+// objects - blobs currently being tracked (e.g. Blobies.Objects or Blobies.ObjectsInState(...)).
+// Blobs which were inside of the zone, but are not present in objects anymore, produce ZoneExit event.
+func (z *Zone) Update(objects map[uuid.UUID]Blobie, frameTime time.Time) []ZoneEvent {
+	events := []ZoneEvent{}
+	for id, b := range objects {
+		track := z.Anchor.Track(b)
+		if len(track) == 0 {
+			continue
+		}
+		inside := z.Polygon.Contains(track[len(track)-1])
+		member, wasInside := z.members[id]
+		switch {
+		case inside && !wasInside:
+			z.members[id] = &zoneMember{enteredAt: frameTime}
+			events = append(events, z.newEvent(ZoneEnter, id, b, frameTime, 0))
+		case inside && wasInside:
+			dwell := frameTime.Sub(member.enteredAt)
+			if z.DwellThreshold > 0 && !member.dwellReported && dwell >= z.DwellThreshold {
+				member.dwellReported = true
+				events = append(events, z.newEvent(ZoneDwell, id, b, frameTime, dwell))
+			}
+		case !inside && wasInside:
+			delete(z.members, id)
+			events = append(events, z.newEvent(ZoneExit, id, b, frameTime, frameTime.Sub(member.enteredAt)))
+		}
+	}
+	for id, member := range z.members {
+		if _, ok := objects[id]; ok {
+			continue
+		}
+		delete(z.members, id)
+		events = append(events, z.newEvent(ZoneExit, id, nil, frameTime, frameTime.Sub(member.enteredAt)))
+	}
+	return events
+}
+
+// Occupancy - Returns number of blobs which are currently inside of the zone
+func (z *Zone) Occupancy() int {
+	return len(z.members)
+}
+
+// DwellTime - Returns time which blob has been spending in the zone at given moment. Second returned value is false if blob is not inside of the zone
+func (z *Zone) DwellTime(id uuid.UUID, now time.Time) (time.Duration, bool) {
+	member, ok := z.members[id]
+	if !ok {
+		return 0, false
+	}
+	return now.Sub(member.enteredAt), true
+}
+
+func (z *Zone) newEvent(eventType ZoneEventType, id uuid.UUID, b Blobie, frameTime time.Time, dwell time.Duration) ZoneEvent {
+	return ZoneEvent{
+		ZoneID: z.ID,
+		Type:   eventType,
+		BlobID: id,
+		Blob:   b,
+		Time:   frameTime,
+		Dwell:  dwell,
+	}
+}
@@ -0,0 +1,87 @@
+package blob
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestPolygonContains(t *testing.T) {
+	// Concave polygon ("L"-shape)
+	polygon := Polygon{
+		image.Pt(0, 0),
+		image.Pt(100, 0),
+		image.Pt(100, 40),
+		image.Pt(40, 40),
+		image.Pt(40, 100),
+		image.Pt(0, 100),
+	}
+	cases := []struct {
+		pt     image.Point
+		inside bool
+	}{
+		{image.Pt(20, 20), true},
+		{image.Pt(80, 20), true},
+		{image.Pt(20, 80), true},
+		{image.Pt(80, 80), false},
+		{image.Pt(100, 20), true}, // on the border
+		{image.Pt(-1, 50), false},
+	}
+	for _, c := range cases {
+		if polygon.Contains(c.pt) != c.inside {
+			t.Errorf("Point %v should be inside: %t, but got %t", c.pt, c.inside, !c.inside)
+		}
+	}
+}
+
+func TestZoneEvents(t *testing.T) {
+	zone, err := NewZone("parking_bay", Polygon{image.Pt(100, 0), image.Pt(200, 0), image.Pt(200, 100), image.Pt(100, 100)}, 3*time.Second)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	allblobies := NewBlobiesDefaults()
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	options := BlobOptions{ClassID: 1, ClassName: "car", MaxPointsInTrack: 10}
+
+	// Blob moves into the zone, stays there for a while, leaves it and then disappears
+	xs := []int{60, 90, 120, 130, 135, 135, 135, 135, 160, 190, 220}
+	counters := map[ZoneEventType]int{}
+	var exitEvent ZoneEvent
+	for i, x := range xs {
+		options.Time = startTime.Add(time.Duration(i) * time.Second)
+		allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(image.Rect(x-30, 30, x+30, 70), &options)})
+		for _, event := range zone.Update(allblobies.Objects, options.Time) {
+			counters[event.Type]++
+			if event.Type == ZoneExit {
+				exitEvent = event
+			}
+		}
+		occupancy := 0
+		if x >= 100 && x <= 200 {
+			occupancy = 1
+		}
+		if zone.Occupancy() != occupancy {
+			t.Errorf("Occupancy on frame %d should be %d, but got %d", i, occupancy, zone.Occupancy())
+		}
+	}
+	correctCounters := map[ZoneEventType]int{ZoneEnter: 1, ZoneDwell: 1, ZoneExit: 1}
+	for eventType, correct := range correctCounters {
+		if counters[eventType] != correct {
+			t.Errorf("Number of '%s' events should be %d, but got %d", eventType, correct, counters[eventType])
+		}
+	}
+	if exitEvent.Dwell != 8*time.Second {
+		t.Errorf("Dwell time on exit should be %s, but got %s", 8*time.Second, exitEvent.Dwell)
+	}
+
+	// Deregistered blob should leave the zone too
+	allblobies.MatchToExisting([]Blobie{NewSimpleBlobie(image.Rect(140, 40, 160, 60), &options)})
+	zone.Update(allblobies.Objects, options.Time)
+	events := zone.Update(map[uuid.UUID]Blobie{}, options.Time.Add(time.Second))
+	if len(events) != 1 || events[0].Type != ZoneExit || events[0].Blob != nil {
+		t.Errorf("Exit event should be emitted for blob which is not tracked anymore, but got %v", events)
+	}
+}
@@ -0,0 +1,223 @@
+// Package calibration provides pixel-to-world calibration of camera's image plane.
+//
+// It does not depend on gocv, so it could be used (and tested) without OpenCV installed.
+package calibration
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"math"
+
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Point - Point with floating point coordinates (e.g. position on the ground plane in meters)
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// NewPointFromImage - Converts image point (pixels) to Point
+func NewPointFromImage(pt image.Point) Point {
+	return Point{X: float64(pt.X), Y: float64(pt.Y)}
+}
+
+// Distance - Returns Euclidean distance between two points
+func Distance(a, b Point) float64 {
+	return math.Hypot(b.X-a.X, b.Y-a.Y)
+}
+
+// Homography - Projective transformation (3x3 matrix) between image plane and ground plane
+type Homography struct {
+	// Matrix - Elements of 3x3 matrix in row-major order
+	Matrix [9]float64 `json:"matrix"`
+}
+
+// homographyEps - Tolerance for singular values and homogeneous coordinates
+const homographyEps = 1e-12
+
+// NewHomography - Estimates homography which maps image points (pixels) to ground points (e.g. meters)
+//
+// At least four pairs of points are required and no three of them should be collinear.
+// Normalized direct linear transformation (DLT) is used, so for more than four pairs least-squares solution is returned
+func NewHomography(imagePoints, groundPoints []Point) (*Homography, error) {
+	if len(imagePoints) != len(groundPoints) {
+		return nil, fmt.Errorf("number of image points (%d) and ground points (%d) must be equal", len(imagePoints), len(groundPoints))
+	}
+	n := len(imagePoints)
+	if n < 4 {
+		return nil, fmt.Errorf("at least 4 pairs of points are required, but got %d", n)
+	}
+	imageT, normImage := normalizePoints(imagePoints)
+	groundT, normGround := normalizePoints(groundPoints)
+
+	a := mat.NewDense(2*n, 9, nil)
+	for i := 0; i < n; i++ {
+		x, y := normImage[i].X, normImage[i].Y
+		u, v := normGround[i].X, normGround[i].Y
+		a.SetRow(2*i, []float64{-x, -y, -1, 0, 0, 0, u * x, u * y, u})
+		a.SetRow(2*i+1, []float64{0, 0, 0, -x, -y, -1, v * x, v * y, v})
+	}
+	var svd mat.SVD
+	if ok := svd.Factorize(a, mat.SVDFull); !ok {
+		return nil, fmt.Errorf("can't evaluate singular value decomposition")
+	}
+	values := svd.Values(nil)
+	// Rank of system must be 8: otherwise points are in degenerate configuration
+	if len(values) < 8 || values[7] < homographyEps*values[0] {
+		return nil, fmt.Errorf("points are in degenerate configuration (e.g. three of them are collinear)")
+	}
+	var v mat.Dense
+	svd.VTo(&v)
+	hNorm := mat.NewDense(3, 3, mat.Col(nil, 8, &v))
+
+	// Denormalize: H = inv(T_ground) * H_norm * T_image
+	var groundTInv mat.Dense
+	if err := groundTInv.Inverse(groundT); err != nil {
+		return nil, errors.Wrap(err, "can't invert normalization matrix")
+	}
+	var h mat.Dense
+	h.Product(&groundTInv, hNorm, imageT)
+	return newHomographyFromDense(&h)
+}
+
+// NewHomographyFromMatrix - Creates homography from elements of 3x3 matrix (row-major order)
+func NewHomographyFromMatrix(matrix [9]float64) (*Homography, error) {
+	return newHomographyFromDense(mat.NewDense(3, 3, matrix[:]))
+}
+
+// newHomographyFromDense - Creates homography from 3x3 matrix. Matrix is scaled so its last element is 1 (if possible)
+func newHomographyFromDense(m *mat.Dense) (*Homography, error) {
+	if math.Abs(mat.Det(m)) < homographyEps {
+		return nil, fmt.Errorf("homography matrix must be non-singular")
+	}
+	h := Homography{}
+	scale := m.At(2, 2)
+	if math.Abs(scale) < homographyEps {
+		scale = 1
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			h.Matrix[i*3+j] = m.At(i, j) / scale
+		}
+	}
+	return &h, nil
+}
+
+// normalizePoints - Returns similarity transformation (Hartley normalization) and transformed points:
+// centroid of transformed points is in the origin and their average distance to the origin is sqrt(2)
+func normalizePoints(points []Point) (*mat.Dense, []Point) {
+	cx, cy := 0.0, 0.0
+	for _, pt := range points {
+		cx += pt.X
+		cy += pt.Y
+	}
+	cx /= float64(len(points))
+	cy /= float64(len(points))
+	meanDistance := 0.0
+	for _, pt := range points {
+		meanDistance += math.Hypot(pt.X-cx, pt.Y-cy)
+	}
+	meanDistance /= float64(len(points))
+	scale := 1.0
+	if meanDistance > 0 {
+		scale = math.Sqrt2 / meanDistance
+	}
+	t := mat.NewDense(3, 3, []float64{
+		scale, 0, -scale * cx,
+		0, scale, -scale * cy,
+		0, 0, 1,
+	})
+	normalized := make([]Point, len(points))
+	for i, pt := range points {
+		normalized[i] = Point{X: scale * (pt.X - cx), Y: scale * (pt.Y - cy)}
+	}
+	return t, normalized
+}
+
+// Project - Projects point via homography
+// Error is returned when point is mapped to infinity (e.g. point is above the horizon)
+func (h *Homography) Project(pt Point) (Point, error) {
+	m := h.Matrix
+	w := m[6]*pt.X + m[7]*pt.Y + m[8]
+	if math.Abs(w) < homographyEps {
+		return Point{}, fmt.Errorf("point (%f, %f) is projected to infinity", pt.X, pt.Y)
+	}
+	return Point{
+		X: (m[0]*pt.X + m[1]*pt.Y + m[2]) / w,
+		Y: (m[3]*pt.X + m[4]*pt.Y + m[5]) / w,
+	}, nil
+}
+
+// ProjectImagePoint - Projects image point (pixels) via homography
+func (h *Homography) ProjectImagePoint(pt image.Point) (Point, error) {
+	return h.Project(NewPointFromImage(pt))
+}
+
+// ProjectTrack - Projects every point of track (e.g. Blobie.GetTrack()) via homography
+func (h *Homography) ProjectTrack(track []image.Point) ([]Point, error) {
+	projected := make([]Point, len(track))
+	for i := range track {
+		pt, err := h.ProjectImagePoint(track[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't project point #%d of track", i)
+		}
+		projected[i] = pt
+	}
+	return projected, nil
+}
+
+// Inverse - Returns inverse homography (e.g. ground plane to image plane)
+func (h *Homography) Inverse() (*Homography, error) {
+	var inv mat.Dense
+	if err := inv.Inverse(mat.NewDense(3, 3, h.Matrix[:])); err != nil {
+		return nil, errors.Wrap(err, "can't invert homography matrix")
+	}
+	return newHomographyFromDense(&inv)
+}
+
+// ReprojectionError - Returns root mean square distance between projected source points and target points
+// Could be used to check quality of calibration
+func (h *Homography) ReprojectionError(sourcePoints, targetPoints []Point) (float64, error) {
+	if len(sourcePoints) != len(targetPoints) || len(sourcePoints) == 0 {
+		return 0, fmt.Errorf("number of source points (%d) and target points (%d) must be equal and non-zero", len(sourcePoints), len(targetPoints))
+	}
+	sum := 0.0
+	for i := range sourcePoints {
+		pt, err := h.Project(sourcePoints[i])
+		if err != nil {
+			return 0, errors.Wrapf(err, "can't project point #%d", i)
+		}
+		d := Distance(pt, targetPoints[i])
+		sum += d * d
+	}
+	return math.Sqrt(sum / float64(len(sourcePoints))), nil
+}
+
+// Save - Saves homography to JSON file
+func (h *Homography) Save(fname string) error {
+	data, err := json.MarshalIndent(h, "", "    ")
+	if err != nil {
+		return errors.Wrap(err, "can't marshal homography")
+	}
+	if err := ioutil.WriteFile(fname, data, 0644); err != nil {
+		return errors.Wrapf(err, "can't write file '%s'", fname)
+	}
+	return nil
+}
+
+// LoadHomography - Loads homography from JSON file (see Save)
+func LoadHomography(fname string) (*Homography, error) {
+	data, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't read file '%s'", fname)
+	}
+	h := Homography{}
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, errors.Wrapf(err, "can't unmarshal homography from file '%s'", fname)
+	}
+	return NewHomographyFromMatrix(h.Matrix)
+}
@@ -0,0 +1,100 @@
+package calibration
+
+import (
+	"image"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestHomography(t *testing.T) {
+	// Ground truth: perspective transformation
+	truth, err := NewHomographyFromMatrix([9]float64{
+		0.05, 0.01, -10,
+		0.002, 0.12, -5,
+		0.0001, 0.002, 1,
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	imagePoints := []Point{{100, 400}, {600, 420}, {550, 150}, {150, 130}, {350, 250}}
+	groundPoints := make([]Point, len(imagePoints))
+	for i := range imagePoints {
+		groundPoints[i], err = truth.Project(imagePoints[i])
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	for _, n := range []int{4, 5} {
+		h, err := NewHomography(imagePoints[:n], groundPoints[:n])
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		for i := range h.Matrix {
+			if math.Abs(h.Matrix[i]-truth.Matrix[i]) > 1e-6 {
+				t.Errorf("Element #%d of homography estimated by %d pairs should be %f, but got %f", i, n, truth.Matrix[i], h.Matrix[i])
+			}
+		}
+		rmse, err := h.ReprojectionError(imagePoints[:n], groundPoints[:n])
+		if err != nil {
+			t.Error(err)
+		}
+		if rmse > 1e-6 {
+			t.Errorf("Reprojection error should be close to zero, but got %f", rmse)
+		}
+	}
+
+	// Track projection and inverse transformation
+	track, err := truth.ProjectTrack([]image.Point{image.Pt(300, 300), image.Pt(320, 280)})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	inv, err := truth.Inverse()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	back, err := inv.Project(track[1])
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if Distance(back, Point{320, 280}) > 1e-6 {
+		t.Errorf("Inverse projection should be %v, but got %v", Point{320, 280}, back)
+	}
+}
+
+func TestHomographyDegenerate(t *testing.T) {
+	if _, err := NewHomography([]Point{{0, 0}, {1, 1}, {2, 2}}, []Point{{0, 0}, {1, 1}, {2, 2}}); err == nil {
+		t.Error("Less than 4 pairs should produce an error")
+	}
+	collinear := []Point{{0, 0}, {1, 1}, {2, 2}, {3, 3}}
+	if _, err := NewHomography(collinear, collinear); err == nil {
+		t.Error("Collinear points should produce an error")
+	}
+}
+
+func TestHomographySaveLoad(t *testing.T) {
+	h, err := NewHomographyFromMatrix([9]float64{2, 0, 1, 0, 3, 2, 0, 0.001, 1})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	fname := filepath.Join(t.TempDir(), "homography.json")
+	if err := h.Save(fname); err != nil {
+		t.Error(err)
+		return
+	}
+	loaded, err := LoadHomography(fname)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if loaded.Matrix != h.Matrix {
+		t.Errorf("Loaded homography should be %v, but got %v", h.Matrix, loaded.Matrix)
+	}
+}
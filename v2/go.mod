@@ -3,7 +3,6 @@ module github.com/LdDl/gocv-blob/v2
 go 1.16
 
 require (
-	github.com/LdDl/kalman-filter v0.1.1
 	github.com/pkg/errors v0.9.1
 	github.com/satori/go.uuid v1.2.0
 	gocv.io/x/gocv v0.30.0